@@ -6,6 +6,12 @@ echo "Deploying to dev environment..."
 # Create the namespace
 kubectl apply -f namespace.yaml
 
+# Create the admin token, unless it exists
+if ! kubectl get secret admin-token -n simple-microservice-project >/dev/null 2>&1; then
+  kubectl create secret generic admin-token -n simple-microservice-project \
+    --from-literal=token="${ADMIN_TOKEN:-$(openssl rand -hex 32)}"
+fi
+
 # Create the config maps
 cd configmaps || return
 kubectl apply -f otel-cm.yaml
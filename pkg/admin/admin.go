@@ -0,0 +1,185 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+	"time"
+
+	"SimpleMicroserviceProject/pkg/buildinfo"
+	"SimpleMicroserviceProject/pkg/config"
+	"SimpleMicroserviceProject/pkg/health"
+	"SimpleMicroserviceProject/pkg/log"
+	"SimpleMicroserviceProject/pkg/middleware"
+	"SimpleMicroserviceProject/pkg/telemetry"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Config describes the admin listener.
+type Config struct {
+	// Addr is the address of the admin server, e.g. ":9090". Empty disables it.
+	Addr string
+	// Token must be sent as "Authorization: Bearer <token>" on every endpoint
+	// except the probes and metrics. Without it, only those are served.
+	Token string
+}
+
+// FromEnv reads the admin configuration from ADMIN_ADDR and ADMIN_TOKEN.
+func FromEnv() Config {
+	return Config{
+		Addr:  config.String("ADMIN_ADDR", ""),
+		Token: config.String("ADMIN_TOKEN", ""),
+	}
+}
+
+// Enabled reports whether an admin address was configured.
+func (c Config) Enabled() bool {
+	return c.Addr != ""
+}
+
+// Server is a second HTTP server for operational endpoints. It is kept off the
+// public port so pprof, metrics and runtime controls are not exposed by the NodePort.
+type Server struct {
+	*middleware.Server
+	mux   *http.ServeMux
+	token string
+}
+
+// NewServer builds the admin server with pprof, expvar, build info, probes and
+// log level endpoints registered. More endpoints can be added with Handle.
+func NewServer(ctx context.Context, cfg Config, opts ...middleware.Option) *Server {
+	s := &Server{
+		mux:   http.NewServeMux(),
+		token: cfg.Token,
+	}
+
+	// CPU profiles and traces stream for up to 30s by default, so the write
+	// timeout is longer than on the public server.
+	opts = append([]middleware.Option{
+		middleware.WithAddr(cfg.Addr),
+		middleware.WithWriteTimeout(time.Minute),
+	}, opts...)
+	opts = append(opts, middleware.WithHandler(s.mux))
+	s.Server = middleware.GetHttpServer(ctx, nil, opts...)
+
+	if s.token == "" {
+		logrus.WithField("addr", cfg.Addr).
+			Warn("ADMIN_TOKEN is not set, pprof and runtime control endpoints are disabled")
+	}
+
+	s.HandlePublic("/livez", health.Handler(health.Liveness))
+	s.HandlePublic("/readyz", health.Handler(health.Readiness))
+	s.HandlePublic("/startupz", health.Handler(health.Startup))
+	// Prometheus scrapes without credentials, like the probes.
+	if metrics := telemetry.MetricsHandler(); metrics != nil {
+		s.HandlePublic("/metrics", metrics)
+	}
+
+	s.Handle("/debug/pprof/", http.HandlerFunc(pprof.Index))
+	s.Handle("/debug/pprof/cmdline", http.HandlerFunc(pprof.Cmdline))
+	s.Handle("/debug/pprof/profile", http.HandlerFunc(pprof.Profile))
+	s.Handle("/debug/pprof/symbol", http.HandlerFunc(pprof.Symbol))
+	s.Handle("/debug/pprof/trace", http.HandlerFunc(pprof.Trace))
+	s.Handle("/debug/vars", expvar.Handler())
+	s.Handle("/buildinfo", http.HandlerFunc(handleBuildInfo))
+	s.Handle("/loglevel", http.HandlerFunc(handleLogLevel))
+
+	return s
+}
+
+// Handle registers an endpoint that requires the admin token. It is not
+// registered when no token is configured.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	if s.token == "" {
+		return
+	}
+	s.mux.Handle(pattern, s.authenticate(handler))
+}
+
+// HandlePublic registers an endpoint reachable without the admin token,
+// used for the kubelet probes.
+func (s *Server) HandlePublic(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func handleBuildInfo(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, buildinfo.Get())
+}
+
+// handleLogLevel reports the log levels on GET, changes them on PUT/POST and
+// removes overrides on DELETE, e.g.
+//
+//	curl -X PUT "localhost:9090/loglevel?level=debug&component=db&revert=10m"
+func handleLogLevel(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	component := query.Get("component")
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		level, err := logrus.ParseLevel(query.Get("level"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var revertAfter time.Duration
+		if revert := query.Get("revert"); revert != "" {
+			if revertAfter, err = time.ParseDuration(revert); err != nil || revertAfter <= 0 {
+				http.Error(w, "invalid revert duration", http.StatusBadRequest)
+				return
+			}
+		}
+
+		switch {
+		case revertAfter > 0:
+			log.SetLevelTemporarily(component, level, revertAfter)
+		case component != "":
+			log.SetComponentLevel(component, level)
+		default:
+			log.SetLevel(level)
+		}
+		logrus.WithFields(logrus.Fields{
+			"level":        level.String(),
+			"component":    component,
+			"revert_after": revertAfter.String(),
+		}).Info("Log level changed")
+	case http.MethodDelete:
+		if component == "" {
+			log.ResetLevels()
+		} else {
+			log.ResetComponentLevel(component)
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, log.GetLevels())
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logrus.WithError(err).Warn("Failed to write admin response")
+	}
+}
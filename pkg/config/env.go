@@ -0,0 +1,69 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// String returns the environment variable key, or def when it is unset or empty.
+func String(key, def string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return def
+}
+
+// Int returns the environment variable key parsed as an int, or def.
+func Int(key string, def int) int {
+	value, err := strconv.Atoi(String(key, ""))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// Float returns the environment variable key parsed as a float64, or def.
+func Float(key string, def float64) float64 {
+	value, err := strconv.ParseFloat(String(key, ""), 64)
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// Bool returns the environment variable key parsed as a bool, or def.
+func Bool(key string, def bool) bool {
+	value, err := strconv.ParseBool(String(key, ""))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// Duration returns the environment variable key parsed as a time.Duration
+// ("5s", "1m"), or def.
+func Duration(key string, def time.Duration) time.Duration {
+	value, err := time.ParseDuration(String(key, ""))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// List returns the comma separated environment variable key as a slice, or def.
+func List(key string, def []string) []string {
+	value := String(key, "")
+	if value == "" {
+		return def
+	}
+
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
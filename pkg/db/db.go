@@ -1,7 +1,14 @@
 package db
 
 import (
-	"github.com/sirupsen/logrus"
+	"context"
+	"errors"
+	"time"
+
+	"SimpleMicroserviceProject/pkg/config"
+	"SimpleMicroserviceProject/pkg/health"
+	"SimpleMicroserviceProject/pkg/log"
+	"SimpleMicroserviceProject/pkg/resilience"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -9,12 +16,61 @@ import (
 
 var DB *gorm.DB
 
+var dbLogger = log.Component(log.ComponentDB)
+
+var (
+	breaker  = resilience.NewCircuitBreaker("postgres", resilience.DefaultBreakerConfig())
+	bulkhead = resilience.NewBulkhead("postgres",
+		config.Int("DB_MAX_CONCURRENCY", 10),
+		config.Duration("DB_MAX_WAIT", 100*time.Millisecond))
+)
+
 // ConnectDatabase initializes the PostgreSQL connection
 func ConnectDatabase() {
 	dsn := "host=postgres-service.default.svc.cluster.local user=postgres password=postgres dbname=postgres port=5432 sslmode=disable"
 	var err error
 	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
 	if err != nil {
-		logrus.Fatal("Failed to connect to database", err)
+		dbLogger.Fatal("Failed to connect to database", err)
+	}
+}
+
+// Execute runs fn against the shared connection, guarded by a circuit breaker
+// and a bulkhead so callers fail fast while Postgres is down or saturated.
+func Execute(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	return bulkhead.Execute(ctx, func(ctx context.Context) error {
+		done, err := breaker.Allow(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = fn(DB.WithContext(ctx))
+		// A missing record is an answer, not a sign of an unhealthy database.
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			done(nil)
+		} else {
+			done(resilience.CallerError(ctx, err))
+		}
+		return err
+	})
+}
+
+// HealthCheck pings Postgres. It runs in the background every 10s so probes
+// don't open a connection on every request.
+func HealthCheck() health.Check {
+	return health.Check{
+		Name:     "postgres",
+		Probes:   []health.Probe{health.Readiness, health.Startup},
+		Interval: 10 * time.Second,
+		Func: func(ctx context.Context) error {
+			if DB == nil {
+				return errors.New("database not connected")
+			}
+			sqlDB, err := DB.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		},
 	}
 }
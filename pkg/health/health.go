@@ -0,0 +1,289 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	applog "SimpleMicroserviceProject/pkg/log"
+)
+
+var healthLogger = applog.Component(applog.ComponentHTTP)
+
+// Probe is the kind of question a check answers.
+type Probe string
+
+const (
+	// Liveness fails when the process is broken and should be restarted.
+	Liveness Probe = "liveness"
+	// Readiness fails when the service should not receive traffic.
+	Readiness Probe = "readiness"
+	// Startup fails until the service has finished starting.
+	Startup Probe = "startup"
+)
+
+// Check is a named health check.
+type Check struct {
+	Name   string
+	Probes []Probe
+	Func   func(ctx context.Context) error
+	// Timeout bounds a single run of Func. Defaults to 2s.
+	Timeout time.Duration
+	// Interval, when set, runs the check in the background and serves the cached
+	// result, for checks too expensive to run on every probe.
+	Interval time.Duration
+	// NonCritical checks are reported but don't fail the probe.
+	NonCritical bool
+}
+
+// Status of a check or a whole report.
+type Status string
+
+const (
+	StatusPass    Status = "pass"
+	StatusFail    Status = "fail"
+	StatusWarn    Status = "warn"
+	StatusUnknown Status = "unknown"
+)
+
+// Result is the outcome of one check.
+type Result struct {
+	Name        string     `json:"name"`
+	Status      Status     `json:"status"`
+	LatencyMs   float64    `json:"latency_ms"`
+	CheckedAt   *time.Time `json:"checked_at,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+// Report is the JSON body served by the probe endpoints.
+type Report struct {
+	Status Status `json:"status"`
+	Probe  Probe  `json:"probe"`
+	// Draining is set on the readiness report once shutdown has begun.
+	Draining bool     `json:"draining,omitempty"`
+	Checks   []Result `json:"checks"`
+}
+
+type registeredCheck struct {
+	check Check
+
+	mu          sync.Mutex
+	ran         bool
+	err         error
+	latency     time.Duration
+	checkedAt   time.Time
+	lastError   string
+	lastErrorAt time.Time
+}
+
+func (c *registeredCheck) run(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, c.check.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.check.Func(ctx)
+	latency := time.Since(start)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ran = true
+	c.err = err
+	c.latency = latency
+	c.checkedAt = time.Now()
+	if err != nil {
+		c.lastError = err.Error()
+		c.lastErrorAt = c.checkedAt
+	}
+}
+
+func (c *registeredCheck) result() Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := Result{Name: c.check.Name, Status: StatusUnknown}
+	if !c.ran {
+		return result
+	}
+
+	checkedAt := c.checkedAt
+	result.CheckedAt = &checkedAt
+	result.LatencyMs = float64(c.latency.Microseconds()) / 1000
+	result.Status = StatusPass
+	if c.err != nil {
+		result.Status = StatusFail
+		if c.check.NonCritical {
+			result.Status = StatusWarn
+		}
+	}
+	if c.lastError != "" {
+		lastErrorAt := c.lastErrorAt
+		result.LastError = c.lastError
+		result.LastErrorAt = &lastErrorAt
+	}
+	return result
+}
+
+// Registry holds the checks of a service and serves the probe endpoints.
+type Registry struct {
+	mu       sync.RWMutex
+	checks   []*registeredCheck
+	ctx      context.Context
+	started  bool
+	draining bool
+}
+
+// Default is the registry used by the package level functions.
+var Default = NewRegistry()
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a check. Background checks registered after Start begin immediately.
+func (r *Registry) Register(check Check) {
+	if check.Timeout <= 0 {
+		check.Timeout = 2 * time.Second
+	}
+	c := &registeredCheck{check: check}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, c)
+	if r.ctx != nil && check.Interval > 0 {
+		go r.runPeriodically(r.ctx, c)
+	}
+}
+
+// Start runs the background checks until ctx is done.
+func (r *Registry) Start(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ctx = ctx
+	for _, c := range r.checks {
+		if c.check.Interval > 0 {
+			go r.runPeriodically(ctx, c)
+		}
+	}
+}
+
+func (r *Registry) runPeriodically(ctx context.Context, c *registeredCheck) {
+	ticker := time.NewTicker(c.check.Interval)
+	defer ticker.Stop()
+	for {
+		c.run(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// MarkStarted lets the startup probe pass once its checks do.
+func (r *Registry) MarkStarted() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.started = true
+}
+
+// MarkDraining makes the readiness probe fail so that traffic is routed away
+// before the server stops accepting connections.
+func (r *Registry) MarkDraining() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.draining = true
+}
+
+// Check evaluates every check registered for probe. Synchronous checks run
+// concurrently; background checks report their cached result.
+func (r *Registry) Check(ctx context.Context, probe Probe) Report {
+	r.mu.RLock()
+	var checks []*registeredCheck
+	for _, c := range r.checks {
+		for _, p := range c.check.Probes {
+			if p == probe {
+				checks = append(checks, c)
+				break
+			}
+		}
+	}
+	started, draining := r.started, r.draining
+	r.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, c := range checks {
+		if c.check.Interval > 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(c *registeredCheck) {
+			defer wg.Done()
+			c.run(ctx)
+		}(c)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusPass, Probe: probe, Checks: []Result{}}
+	if probe == Startup && !started {
+		report.Status = StatusFail
+	}
+	if probe == Readiness && draining {
+		report.Status = StatusFail
+		report.Draining = true
+	}
+	for _, c := range checks {
+		result := c.result()
+		report.Checks = append(report.Checks, result)
+		if result.Status == StatusFail || (result.Status == StatusUnknown && !c.check.NonCritical) {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// Handler serves the JSON report for probe, with 503 when it fails.
+func (r *Registry) Handler(probe Probe) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		report := r.Check(req.Context(), probe)
+
+		status := http.StatusOK
+		if report.Status == StatusFail {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			healthLogger.WithError(err).Warn("Failed to write health report")
+		}
+	}
+}
+
+// Register adds a check to the default registry.
+func Register(check Check) {
+	Default.Register(check)
+}
+
+// Start runs the default registry's background checks until ctx is done.
+func Start(ctx context.Context) {
+	Default.Start(ctx)
+}
+
+// MarkStarted marks the service as started in the default registry.
+func MarkStarted() {
+	Default.MarkStarted()
+}
+
+// MarkDraining fails the readiness probe of the default registry.
+func MarkDraining() {
+	Default.MarkDraining()
+}
+
+// Handler serves probe from the default registry.
+func Handler(probe Probe) http.HandlerFunc {
+	return Default.Handler(probe)
+}
@@ -0,0 +1,86 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// WorkerMonitor tracks a worker pool so health checks can tell whether its
+// goroutines are alive and its queue is moving.
+type WorkerMonitor struct {
+	name         string
+	depth        func() int
+	alive        atomic.Int64
+	lastProgress atomic.Int64
+	queuedSince  atomic.Int64
+}
+
+// NewWorkerMonitor returns a monitor for the named pool; depth reports the
+// number of queued jobs, e.g. func() int { return len(GetOrderChannel()) }.
+func NewWorkerMonitor(name string, depth func() int) *WorkerMonitor {
+	m := &WorkerMonitor{name: name, depth: depth}
+	m.lastProgress.Store(time.Now().UnixNano())
+	return m
+}
+
+// Started is called when a worker goroutine starts.
+func (m *WorkerMonitor) Started() {
+	m.alive.Add(1)
+}
+
+// Stopped is called when a worker goroutine exits.
+func (m *WorkerMonitor) Stopped() {
+	m.alive.Add(-1)
+}
+
+// Progress is called whenever a worker finished a job.
+func (m *WorkerMonitor) Progress() {
+	m.lastProgress.Store(time.Now().UnixNano())
+}
+
+// Alive returns the number of running worker goroutines.
+func (m *WorkerMonitor) Alive() int {
+	return int(m.alive.Load())
+}
+
+// AliveCheck fails when fewer than minWorkers goroutines are running.
+func (m *WorkerMonitor) AliveCheck(minWorkers int) Check {
+	return Check{
+		Name:   m.name + "-workers",
+		Probes: []Probe{Liveness, Readiness},
+		Func: func(context.Context) error {
+			if alive := m.Alive(); alive < minWorkers {
+				return fmt.Errorf("%d of %d workers alive", alive, minWorkers)
+			}
+			return nil
+		},
+	}
+}
+
+// QueueCheck fails when jobs are queued but none completed within stuckAfter.
+func (m *WorkerMonitor) QueueCheck(stuckAfter time.Duration) Check {
+	return Check{
+		Name:   m.name + "-queue",
+		Probes: []Probe{Liveness, Readiness},
+		Func: func(context.Context) error {
+			depth := m.depth()
+			if depth == 0 {
+				m.queuedSince.Store(0)
+				return nil
+			}
+
+			// Measure from whichever is later: the last completed job or the moment
+			// the queue was first seen non-empty, so an idle pool isn't reported
+			// as stuck as soon as a job arrives.
+			now := time.Now().UnixNano()
+			m.queuedSince.CompareAndSwap(0, now)
+			idle := time.Duration(now - max(m.lastProgress.Load(), m.queuedSince.Load()))
+			if idle > stuckAfter {
+				return fmt.Errorf("%d jobs queued, no progress for %s", depth, idle.Round(time.Second))
+			}
+			return nil
+		},
+	}
+}
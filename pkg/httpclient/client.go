@@ -0,0 +1,281 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	applog "SimpleMicroserviceProject/pkg/log"
+	"SimpleMicroserviceProject/pkg/middleware"
+	"SimpleMicroserviceProject/pkg/requestid"
+	"SimpleMicroserviceProject/pkg/tlsconfig"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const meterName = "SimpleMicroserviceProject/pkg/httpclient"
+
+var clientLogger = applog.Component(applog.ComponentHTTP)
+
+// Client is an instrumented HTTP client for calls between services. It traces
+// every attempt, forwards trace context, baggage, request ID and deadline,
+// retries idempotent calls within a retry budget and can guard each target
+// host with a circuit breaker and bulkhead.
+type Client struct {
+	client  *http.Client
+	timeout time.Duration
+	tls     *tlsconfig.Reloader
+	retry   RetryPolicy
+	budget  *retryBudget
+	guards  hostGuards
+
+	calls    metric.Float64Histogram
+	retries  metric.Int64Counter
+	exceeded metric.Int64Counter
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithTimeout sets the default per-call timeout, covering all attempts.
+// A shorter deadline on the request context takes precedence.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetryPolicy replaces the default retry policy.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// WithRetryBudget allows retries for up to ratio of the calls (0.2 = 20%),
+// with a reserve of maxTokens retries for bursts.
+func WithRetryBudget(ratio float64, maxTokens float64) Option {
+	return func(c *Client) {
+		c.budget = newRetryBudget(ratio, maxTokens)
+	}
+}
+
+// WithTLS presents the service certificate and verifies servers against the
+// configured CA, picking up rotated certificates for new connections. It
+// applies to the transport of WithTransport too, which must then be an
+// *http.Transport.
+func WithTLS(reloader *tlsconfig.Reloader) Option {
+	return func(c *Client) {
+		c.tls = reloader
+	}
+}
+
+// WithTransport sets the transport the instrumentation wraps.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Client) {
+		c.client.Transport = transport
+	}
+}
+
+// New returns a client with a 10s timeout, the default retry policy and a 20% retry budget.
+func New(opts ...Option) *Client {
+	c := &Client{
+		client:  &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()},
+		timeout: 10 * time.Second,
+		retry:   DefaultRetryPolicy(),
+		budget:  newRetryBudget(0.2, 10),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.tls != nil {
+		// Options may come in any order, so TLS is set up once the transport is known.
+		if transport, ok := c.client.Transport.(*http.Transport); ok {
+			// Clone so a shared transport, e.g. http.DefaultTransport, is left alone.
+			transport = transport.Clone()
+			c.tls.ConfigureTransport(transport)
+			c.client.Transport = transport
+		} else {
+			clientLogger.WithField("transport", fmt.Sprintf("%T", c.client.Transport)).
+				Error("WithTLS needs an *http.Transport, calls are made without the service certificate")
+		}
+	}
+
+	// otelhttp creates a client span per attempt and injects the trace context
+	// and baggage with the global propagator.
+	c.client.Transport = otelhttp.NewTransport(c.client.Transport)
+
+	meter := otel.Meter(meterName)
+	var err error
+	c.calls, err = meter.Float64Histogram("http.client.call.duration",
+		metric.WithDescription("Duration of client calls including retries"),
+		metric.WithUnit("s"))
+	if err != nil {
+		clientLogger.WithError(err).Warn("Failed to create client call histogram")
+	}
+	c.retries, err = meter.Int64Counter("http.client.retries",
+		metric.WithDescription("The number of retried client attempts"),
+		metric.WithUnit("{retry}"))
+	if err != nil {
+		clientLogger.WithError(err).Warn("Failed to create client retry counter")
+	}
+	c.exceeded, err = meter.Int64Counter("http.client.retry_budget_exceeded",
+		metric.WithDescription("The number of retries skipped because the retry budget was exhausted"),
+		metric.WithUnit("{retry}"))
+	if err != nil {
+		clientLogger.WithError(err).Warn("Failed to create client retry budget counter")
+	}
+	return c
+}
+
+// Get issues a GET request to url.
+func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// Do sends the request, retrying transient failures of idempotent requests.
+// Requests with a body are only retried when req.GetBody is set.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	ctx := req.Context()
+	cancel := context.CancelFunc(func() {})
+	// An earlier deadline on the request context still wins.
+	if c.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+	}
+	// Clone so the headers added here don't leak into the caller's request.
+	req = req.Clone(ctx)
+
+	hostAttr := attribute.String("server.address", req.URL.Host)
+	c.budget.deposit()
+
+	done, err := c.guards.acquire(ctx, req.URL.Host)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp, err := c.do(req, hostAttr)
+	done(resp, err)
+	if c.calls != nil {
+		outcome := "error"
+		if err == nil {
+			outcome = strconv.Itoa(resp.StatusCode/100) + "xx"
+		}
+		c.calls.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(
+			hostAttr,
+			attribute.String("http.request.method", req.Method),
+			attribute.String("outcome", outcome),
+		))
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// The timeout has to outlive Do until the caller has read the body.
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+func (c *Client) do(req *http.Request, hostAttr attribute.KeyValue) (*http.Response, error) {
+	canRetry := isIdempotent(req) && (req.Body == nil || req.GetBody != nil)
+
+	for attempt := 1; ; attempt++ {
+		propagate(req)
+		resp, err := c.client.Do(req)
+
+		retryable := err != nil && req.Context().Err() == nil
+		if err == nil {
+			retryable = isRetryableStatus(resp.StatusCode)
+		}
+		if !retryable || !canRetry || attempt >= c.retry.MaxAttempts {
+			return resp, err
+		}
+
+		delay := c.retry.backoff(attempt)
+		if resp != nil {
+			delay = max(delay, retryAfter(resp))
+		}
+		if c.retry.MaxBackoff > 0 {
+			delay = min(delay, c.retry.MaxBackoff)
+		}
+		// Waiting past the deadline would only replace this result with a context error.
+		if deadline, ok := req.Context().Deadline(); ok && time.Until(deadline) <= delay {
+			return resp, err
+		}
+		if !c.budget.withdraw() {
+			if c.exceeded != nil {
+				c.exceeded.Add(req.Context(), 1, metric.WithAttributes(hostAttr))
+			}
+			return resp, err
+		}
+
+		if resp != nil {
+			// Drain so the connection can be reused.
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			_ = resp.Body.Close()
+		}
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("httpclient: failed to rewind body: %w", err)
+			}
+			req.Body = body
+		}
+		if c.retries != nil {
+			c.retries.Add(req.Context(), 1, metric.WithAttributes(hostAttr))
+		}
+		clientLogger.WithContext(req.Context()).
+			WithField("url", req.URL.Redacted()).
+			WithField("attempt", attempt+1).
+			Debug("Retrying request")
+	}
+}
+
+// propagate forwards the request ID and the remaining deadline. Trace context
+// and baggage are injected by the otelhttp transport.
+func propagate(req *http.Request) {
+	if id := requestid.FromContext(req.Context()); id != "" && req.Header.Get(requestid.Header) == "" {
+		req.Header.Set(requestid.Header, id)
+	}
+	if deadline, ok := req.Context().Deadline(); ok {
+		if remaining := time.Until(deadline).Milliseconds(); remaining > 0 {
+			req.Header.Set(middleware.TimeoutHeader, strconv.FormatInt(remaining, 10))
+		}
+	}
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
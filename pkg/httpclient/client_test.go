@@ -0,0 +1,103 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// slowServer answers after delay, or when the client gives up.
+func slowServer(t *testing.T, delay time.Duration) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestTimeout(t *testing.T) {
+	server := slowServer(t, 5*time.Second)
+
+	tests := []struct {
+		name           string
+		clientTimeout  time.Duration
+		callerDeadline time.Duration
+		want           time.Duration
+	}{
+		{name: "longer caller deadline", clientTimeout: 50 * time.Millisecond, callerDeadline: time.Minute, want: 50 * time.Millisecond},
+		{name: "shorter caller deadline", clientTimeout: time.Minute, callerDeadline: 50 * time.Millisecond, want: 50 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := New(WithTimeout(tt.clientTimeout), WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+			ctx, cancel := context.WithTimeout(context.Background(), tt.callerDeadline)
+			defer cancel()
+
+			start := time.Now()
+			resp, err := client.Get(ctx, server.URL)
+			if err == nil {
+				resp.Body.Close()
+				t.Fatal("call succeeded, want a deadline error")
+			}
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("err = %v, want %v", err, context.DeadlineExceeded)
+			}
+			if elapsed := time.Since(start); elapsed < tt.want || elapsed > tt.want+time.Second {
+				t.Errorf("call took %v, want about %v", elapsed, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, MaxBackoff: 50 * time.Millisecond, Multiplier: 2}
+
+	tests := []struct {
+		name       string
+		timeout    time.Duration
+		wantStatus int
+		wantCalls  int
+	}{
+		// Retry-After is capped at MaxBackoff, so the retry happens in time.
+		{name: "capped", timeout: 5 * time.Second, wantStatus: http.StatusOK, wantCalls: 2},
+		// The capped delay still passes the deadline, so the 503 is returned.
+		{name: "past deadline", timeout: 20 * time.Millisecond, wantStatus: http.StatusServiceUnavailable, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if calls == 1 {
+					w.Header().Set("Retry-After", "3600")
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			}))
+			defer server.Close()
+
+			client := New(WithTimeout(tt.timeout), WithRetryPolicy(policy))
+			start := time.Now()
+			resp, err := client.Get(context.Background(), server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("server was called %d times, want %d", calls, tt.wantCalls)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("call took %v", elapsed)
+			}
+		})
+	}
+}
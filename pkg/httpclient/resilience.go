@@ -0,0 +1,99 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"SimpleMicroserviceProject/pkg/resilience"
+)
+
+// WithCircuitBreaker guards every target host with its own circuit breaker.
+// Transport errors and 5xx responses count as failures.
+func WithCircuitBreaker(cfg resilience.BreakerConfig) Option {
+	return func(c *Client) {
+		c.guards.breakerConfig = &cfg
+	}
+}
+
+// WithBulkhead caps the number of concurrent calls per target host. Calls wait
+// up to maxWait for a free slot before failing with resilience.ErrBulkheadFull.
+func WithBulkhead(maxConcurrent int, maxWait time.Duration) Option {
+	return func(c *Client) {
+		c.guards.bulkheadSize = maxConcurrent
+		c.guards.bulkheadWait = maxWait
+	}
+}
+
+// hostGuards lazily creates one circuit breaker and bulkhead per target host.
+type hostGuards struct {
+	breakerConfig *resilience.BreakerConfig
+	bulkheadSize  int
+	bulkheadWait  time.Duration
+
+	mu        sync.Mutex
+	breakers  map[string]*resilience.CircuitBreaker
+	bulkheads map[string]*resilience.Bulkhead
+}
+
+// acquire admits a call to host. The returned function must be called with
+// the call's result.
+func (g *hostGuards) acquire(ctx context.Context, host string) (func(*http.Response, error), error) {
+	breaker, bulkhead := g.forHost(host)
+
+	releaseSlot := func() {}
+	if bulkhead != nil {
+		release, err := bulkhead.Acquire(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("httpclient: %s: %w", host, err)
+		}
+		releaseSlot = release
+	}
+
+	done := func(error) {}
+	if breaker != nil {
+		var err error
+		if done, err = breaker.Allow(ctx); err != nil {
+			releaseSlot()
+			return nil, fmt.Errorf("httpclient: %s: %w", host, err)
+		}
+	}
+
+	return func(resp *http.Response, err error) {
+		if err == nil && resp.StatusCode >= http.StatusInternalServerError {
+			err = fmt.Errorf("httpclient: server error %d", resp.StatusCode)
+		}
+		done(resilience.CallerError(ctx, err))
+		releaseSlot()
+	}, nil
+}
+
+func (g *hostGuards) forHost(host string) (*resilience.CircuitBreaker, *resilience.Bulkhead) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var breaker *resilience.CircuitBreaker
+	if g.breakerConfig != nil {
+		if breaker = g.breakers[host]; breaker == nil {
+			if g.breakers == nil {
+				g.breakers = map[string]*resilience.CircuitBreaker{}
+			}
+			breaker = resilience.NewCircuitBreaker("http:"+host, *g.breakerConfig)
+			g.breakers[host] = breaker
+		}
+	}
+
+	var bulkhead *resilience.Bulkhead
+	if g.bulkheadSize > 0 {
+		if bulkhead = g.bulkheads[host]; bulkhead == nil {
+			if g.bulkheads == nil {
+				g.bulkheads = map[string]*resilience.Bulkhead{}
+			}
+			bulkhead = resilience.NewBulkhead("http:"+host, g.bulkheadSize, g.bulkheadWait)
+			g.bulkheads[host] = bulkhead
+		}
+	}
+	return breaker, bulkhead
+}
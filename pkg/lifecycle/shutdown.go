@@ -0,0 +1,171 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"SimpleMicroserviceProject/pkg/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Phase is one step of the shutdown sequence.
+type Phase struct {
+	Name string
+	// Timeout bounds the phase. Zero means it only ends with the overall context.
+	Timeout time.Duration
+	Run     func(ctx context.Context) error
+}
+
+// Coordinator runs shutdown phases in order, logging how long each one took.
+// A failing phase is logged and the remaining phases still run.
+type Coordinator struct {
+	logger *logrus.Logger
+	phases []Phase
+
+	mu     sync.Mutex
+	failed map[string]bool
+}
+
+// NewCoordinator returns an empty coordinator logging to logger.
+func NewCoordinator(logger *logrus.Logger) *Coordinator {
+	return &Coordinator{logger: logger, failed: make(map[string]bool)}
+}
+
+// Add appends a phase.
+func (c *Coordinator) Add(name string, timeout time.Duration, run func(ctx context.Context) error) {
+	c.phases = append(c.phases, Phase{Name: name, Timeout: timeout, Run: run})
+}
+
+// Shutdown runs every phase and returns their joined errors.
+func (c *Coordinator) Shutdown(ctx context.Context) error {
+	start := time.Now()
+	var joinedErr error
+
+	for _, phase := range c.phases {
+		phaseCtx, cancel := ctx, context.CancelFunc(func() {})
+		if phase.Timeout > 0 {
+			phaseCtx, cancel = context.WithTimeout(ctx, phase.Timeout)
+		}
+
+		phaseStart := time.Now()
+		err := phase.Run(phaseCtx)
+		cancel()
+
+		entry := c.logger.WithField("phase", phase.Name).
+			WithField("duration_ms", time.Since(phaseStart).Milliseconds())
+		c.mu.Lock()
+		c.failed[phase.Name] = err != nil
+		c.mu.Unlock()
+		if err != nil {
+			entry.WithError(err).Error("Shutdown phase failed")
+			joinedErr = errors.Join(joinedErr, fmt.Errorf("%s: %w", phase.Name, err))
+			continue
+		}
+		entry.Info("Shutdown phase completed")
+	}
+
+	c.logger.WithField("duration_ms", time.Since(start).Milliseconds()).Info("Shutdown completed")
+	return joinedErr
+}
+
+// Succeeded reports whether the named phase already ran without error.
+func (c *Coordinator) Succeeded(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	failed, ran := c.failed[name]
+	return ran && !failed
+}
+
+// DrainPeriod returns SHUTDOWN_DRAIN_PERIOD, the time between failing readiness
+// and closing the listener, so Kubernetes can remove the pod from its endpoints.
+func DrainPeriod() time.Duration {
+	return config.Duration("SHUTDOWN_DRAIN_PERIOD", 5*time.Second)
+}
+
+// WorkerTimeout returns SHUTDOWN_WORKER_TIMEOUT, how long workers may keep
+// processing queued jobs once the server stopped accepting requests.
+func WorkerTimeout() time.Duration {
+	return config.Duration("SHUTDOWN_WORKER_TIMEOUT", 20*time.Second)
+}
+
+// Wait sleeps for d or until ctx is done.
+func Wait(d time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// DrainQueue closes the queue so workers finish the jobs already in it and
+// exit. If they don't finish before the phase deadline, done is closed to stop
+// them after their current job, and every job left in the queue is passed to
+// unprocessed so it can be persisted or reported.
+//
+// The queue is only closed once sendersStopped reports true, e.g. after the
+// HTTP server shut down. Otherwise a sender still blocked on it would panic;
+// the workers are stopped right away and the queued jobs reported instead.
+func DrainQueue[T any](queue chan T, wg *sync.WaitGroup, done chan struct{}, sendersStopped func() bool, unprocessed func(T)) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		finished := make(chan struct{})
+		if !sendersStopped() {
+			close(done)
+			go func() {
+				wg.Wait()
+				close(finished)
+			}()
+			select {
+			case <-finished:
+			case <-ctx.Done():
+			}
+			left := drainOpen(queue, unprocessed)
+			return fmt.Errorf("senders still running, %d queued jobs left unprocessed", left)
+		}
+
+		close(queue)
+		go func() {
+			wg.Wait()
+			close(finished)
+		}()
+
+		select {
+		case <-finished:
+			close(done)
+			return nil
+		case <-ctx.Done():
+		}
+
+		close(done)
+		<-finished
+
+		var left int
+		for job := range queue {
+			unprocessed(job)
+			left++
+		}
+		return fmt.Errorf("%d queued jobs left unprocessed: %w", left, ctx.Err())
+	}
+}
+
+// drainOpen passes the jobs currently in queue to unprocessed without closing it.
+func drainOpen[T any](queue chan T, unprocessed func(T)) int {
+	var left int
+	for {
+		select {
+		case job := <-queue:
+			unprocessed(job)
+			left++
+		default:
+			return left
+		}
+	}
+}
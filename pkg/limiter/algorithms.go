@@ -0,0 +1,114 @@
+package limiter
+
+import (
+	"math"
+	"time"
+)
+
+// AIMD grows the limit by one while requests succeed and the limit is actually
+// used, and multiplies it by BackoffRatio when a request is dropped or slow.
+type AIMD struct {
+	limit        float64
+	minLimit     int
+	maxLimit     int
+	timeout      time.Duration
+	backoffRatio float64
+}
+
+// NewAIMD returns an additive-increase/multiplicative-decrease algorithm.
+func NewAIMD(cfg Config) *AIMD {
+	backoffRatio := cfg.BackoffRatio
+	if backoffRatio <= 0 || backoffRatio >= 1 {
+		backoffRatio = 0.9
+	}
+	return &AIMD{
+		limit:        clamp(float64(cfg.InitialLimit), cfg.MinLimit, cfg.MaxLimit),
+		minLimit:     cfg.MinLimit,
+		maxLimit:     cfg.MaxLimit,
+		timeout:      cfg.Timeout,
+		backoffRatio: backoffRatio,
+	}
+}
+
+func (a *AIMD) Update(sample Sample) int {
+	switch {
+	case sample.Dropped || (a.timeout > 0 && sample.RTT > a.timeout):
+		a.limit *= a.backoffRatio
+	case float64(sample.InFlight)*2 >= a.limit:
+		// Only grow when at least half the limit is in use, otherwise an idle
+		// service would inflate its limit without evidence it can cope.
+		a.limit++
+	}
+	a.limit = clamp(a.limit, a.minLimit, a.maxLimit)
+	return a.Limit()
+}
+
+func (a *AIMD) Limit() int {
+	return int(a.limit)
+}
+
+// Gradient follows the ratio between the long term and the current latency,
+// as in Netflix's Gradient2 limiter: when latency rises above its baseline the
+// limit shrinks, when it stays at the baseline the limit grows by a queue
+// allowance of sqrt(limit).
+type Gradient struct {
+	limit     float64
+	minLimit  int
+	maxLimit  int
+	smoothing float64
+	tolerance float64
+	longRTT   float64
+	samples   int
+}
+
+// NewGradient returns a gradient based algorithm.
+func NewGradient(cfg Config) *Gradient {
+	smoothing := cfg.Smoothing
+	if smoothing <= 0 || smoothing > 1 {
+		smoothing = 0.2
+	}
+	return &Gradient{
+		limit:     clamp(float64(cfg.InitialLimit), cfg.MinLimit, cfg.MaxLimit),
+		minLimit:  cfg.MinLimit,
+		maxLimit:  cfg.MaxLimit,
+		smoothing: smoothing,
+		tolerance: 1.5,
+	}
+}
+
+func (g *Gradient) Update(sample Sample) int {
+	shortRTT := float64(sample.RTT)
+	if shortRTT <= 0 {
+		return g.Limit()
+	}
+
+	// The long term RTT warms up as a plain average and then decays over ~600 samples.
+	g.samples++
+	if g.samples <= 10 {
+		g.longRTT += (shortRTT - g.longRTT) / float64(g.samples)
+	} else {
+		g.longRTT += (shortRTT - g.longRTT) / 600
+	}
+	// Let the baseline recover quickly after a latency spike.
+	if g.longRTT/shortRTT > 2 {
+		g.longRTT *= 0.95
+	}
+
+	// Don't grow the limit while the service isn't using it.
+	if float64(sample.InFlight) < g.limit/2 {
+		return g.Limit()
+	}
+
+	gradient := math.Max(0.5, math.Min(1.0, g.tolerance*g.longRTT/shortRTT))
+	if sample.Dropped {
+		gradient = 0.5
+	}
+	newLimit := g.limit*gradient + math.Sqrt(g.limit)
+	newLimit = g.limit*(1-g.smoothing) + newLimit*g.smoothing
+	g.limit = clamp(newLimit, g.minLimit, g.maxLimit)
+	return g.Limit()
+}
+
+func (g *Gradient) Limit() int {
+	return int(g.limit)
+}
@@ -0,0 +1,165 @@
+package limiter
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"SimpleMicroserviceProject/pkg/config"
+)
+
+// Sample is one completed request as seen by the limit algorithm.
+type Sample struct {
+	RTT      time.Duration
+	InFlight int
+	// Dropped is set when the request timed out or was rejected downstream,
+	// which is a strong signal to back off.
+	Dropped bool
+}
+
+// Algorithm computes the concurrency limit from request samples.
+type Algorithm interface {
+	// Update feeds a sample and returns the new limit.
+	Update(sample Sample) int
+	Limit() int
+}
+
+// Config selects and tunes the limit algorithm.
+type Config struct {
+	// Algorithm is "gradient", "aimd" or empty to disable limiting.
+	Algorithm    string
+	InitialLimit int
+	MinLimit     int
+	MaxLimit     int
+	// Timeout is the latency above which AIMD treats a request as dropped.
+	Timeout time.Duration
+	// BackoffRatio is the factor AIMD multiplies the limit with on a drop.
+	BackoffRatio float64
+	// Smoothing (0-1] controls how fast the gradient limit follows new samples.
+	Smoothing float64
+}
+
+// ConfigFromEnv reads the CONCURRENCY_LIMIT_* environment variables.
+func ConfigFromEnv() Config {
+	return Config{
+		Algorithm:    strings.ToLower(config.String("CONCURRENCY_LIMIT_ALGORITHM", "")),
+		InitialLimit: config.Int("CONCURRENCY_LIMIT_INITIAL", 20),
+		MinLimit:     config.Int("CONCURRENCY_LIMIT_MIN", 1),
+		MaxLimit:     config.Int("CONCURRENCY_LIMIT_MAX", 200),
+		Timeout:      config.Duration("CONCURRENCY_LIMIT_TIMEOUT", 5*time.Second),
+		BackoffRatio: config.Float("CONCURRENCY_LIMIT_BACKOFF_RATIO", 0.9),
+		Smoothing:    config.Float("CONCURRENCY_LIMIT_SMOOTHING", 0.2),
+	}
+}
+
+// Enabled reports whether an algorithm was selected.
+func (c Config) Enabled() bool {
+	return c.Algorithm != ""
+}
+
+// NewAlgorithm builds the algorithm named in the config.
+func NewAlgorithm(cfg Config) (Algorithm, error) {
+	switch cfg.Algorithm {
+	case "gradient":
+		return NewGradient(cfg), nil
+	case "aimd":
+		return NewAIMD(cfg), nil
+	default:
+		return nil, fmt.Errorf("limiter: unknown algorithm %q", cfg.Algorithm)
+	}
+}
+
+// Limiter admits requests while fewer than the current limit are in flight
+// and feeds the latency of completed requests back into the algorithm.
+type Limiter struct {
+	mu        sync.Mutex
+	algorithm Algorithm
+	inFlight  int
+	lastRTT   time.Duration
+	avgRTT    time.Duration
+}
+
+// New returns a limiter driven by the given algorithm.
+func New(algorithm Algorithm) *Limiter {
+	return &Limiter{algorithm: algorithm}
+}
+
+// Token is handed out by Acquire and must be released exactly once.
+type Token struct {
+	limiter  *Limiter
+	start    time.Time
+	inFlight int
+}
+
+// Acquire reserves a slot. It returns false without blocking when the limit is reached.
+func (l *Limiter) Acquire() (*Token, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.inFlight >= l.algorithm.Limit() {
+		return nil, false
+	}
+	l.inFlight++
+	return &Token{limiter: l, start: time.Now(), inFlight: l.inFlight}, true
+}
+
+// Release records a successful request.
+func (t *Token) Release() {
+	t.limiter.release(t, false)
+}
+
+// Dropped records a request that timed out or was rejected downstream.
+func (t *Token) Dropped() {
+	t.limiter.release(t, true)
+}
+
+func (l *Limiter) release(t *Token, dropped bool) {
+	rtt := time.Since(t.start)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+	l.lastRTT = rtt
+	if l.avgRTT == 0 {
+		l.avgRTT = rtt
+	} else {
+		// Exponentially weighted moving average over roughly the last 20 samples.
+		l.avgRTT += (rtt - l.avgRTT) / 20
+	}
+	l.algorithm.Update(Sample{RTT: rtt, InFlight: t.inFlight, Dropped: dropped})
+}
+
+// Stats is a snapshot of the limiter for metrics.
+type Stats struct {
+	Limit    int
+	InFlight int
+	LastRTT  time.Duration
+	AvgRTT   time.Duration
+}
+
+// Stats returns the current limit, in-flight count and RTT estimates.
+func (l *Limiter) Stats() Stats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return Stats{
+		Limit:    l.algorithm.Limit(),
+		InFlight: l.inFlight,
+		LastRTT:  l.lastRTT,
+		AvgRTT:   l.avgRTT,
+	}
+}
+
+// clamp keeps the limit within [minLimit, maxLimit] and never below one,
+// since a limit of zero would reject every request and never recover.
+func clamp(limit float64, minLimit, maxLimit int) float64 {
+	minLimit = max(minLimit, 1)
+	if limit < float64(minLimit) {
+		return float64(minLimit)
+	}
+	if maxLimit > 0 && limit > float64(maxLimit) {
+		return float64(maxLimit)
+	}
+	return limit
+}
@@ -0,0 +1,88 @@
+package log
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"runtime/debug"
+	"strings"
+)
+
+// ecsVersion is the Elastic Common Schema version the output follows.
+const ecsVersion = "8.11.0"
+
+// ecsFields maps the field names used across the services to ECS fields.
+var ecsFields = map[string]string{
+	"service":     "service.name",
+	"trace_id":    "trace.id",
+	"span_id":     "span.id",
+	"request_id":  "http.request.id",
+	"component":   "log.logger",
+	"method":      "http.request.method",
+	"path":        "url.path",
+	"status":      "http.response.status_code",
+	"error":       "error.message",
+	"stack_trace": "error.stack_trace",
+}
+
+// ECSHandler writes records as Elastic Common Schema JSON, so Elasticsearch
+// can index them without custom parsing. Errors logged at error level or
+// above get the stack trace of the logging goroutine.
+type ECSHandler struct {
+	slog.Handler
+}
+
+// NewECSHandler returns an ECS handler writing to w.
+func NewECSHandler(w io.Writer, level slog.Leveler) *ECSHandler {
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: replaceECSAttr,
+	})
+	return &ECSHandler{Handler: handler.WithAttrs([]slog.Attr{slog.String("ecs.version", ecsVersion)})}
+}
+
+func (h *ECSHandler) Handle(ctx context.Context, record slog.Record) error {
+	if record.Level >= slog.LevelError {
+		hasError, hasStack := false, false
+		record.Attrs(func(attr slog.Attr) bool {
+			hasError = hasError || attr.Key == "error"
+			hasStack = hasStack || attr.Key == "stack_trace"
+			return true
+		})
+		if hasError && !hasStack {
+			record.AddAttrs(slog.String("stack_trace", string(debug.Stack())))
+		}
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *ECSHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ECSHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *ECSHandler) WithGroup(name string) slog.Handler {
+	return &ECSHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+func replaceECSAttr(groups []string, attr slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return attr
+	}
+	switch attr.Key {
+	case slog.TimeKey:
+		return slog.String("@timestamp", attr.Value.Time().UTC().Format("2006-01-02T15:04:05.000Z07:00"))
+	case slog.LevelKey:
+		return slog.String("log.level", strings.ToLower(fromSlogLevel(attr.Value.Any().(slog.Level)).String()))
+	case slog.MessageKey:
+		return slog.Attr{Key: "message", Value: attr.Value}
+	case "duration_ms":
+		// ECS durations are in nanoseconds.
+		if attr.Value.Kind() == slog.KindInt64 {
+			return slog.Int64("event.duration", attr.Value.Int64()*1e6)
+		}
+	}
+	if key, ok := ecsFields[attr.Key]; ok {
+		return slog.Attr{Key: key, Value: attr.Value}
+	}
+	return attr
+}
@@ -0,0 +1,281 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"SimpleMicroserviceProject/pkg/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Components whose verbosity can be changed independently of the global level.
+const (
+	ComponentHTTP       = "http"
+	ComponentDB         = "db"
+	ComponentWorker     = "worker"
+	ComponentTelemetry  = "telemetry"
+	ComponentResilience = "resilience"
+)
+
+// levelState holds the global level, per component overrides and every logger
+// that has to follow them. The empty component name stands for the global level.
+type levelState struct {
+	mu         sync.Mutex
+	level      logrus.Level
+	overrides  map[string]logrus.Level
+	loggers    []*logrus.Logger
+	components map[string]*logrus.Logger
+	slogLevels map[string]*slog.LevelVar
+	reverts    map[string]*time.Timer
+}
+
+var state = newLevelState()
+
+func newLevelState() *levelState {
+	s := &levelState{
+		level:      DefaultLevel(),
+		overrides:  map[string]logrus.Level{},
+		loggers:    []*logrus.Logger{logrus.StandardLogger()},
+		components: map[string]*logrus.Logger{},
+		slogLevels: map[string]*slog.LevelVar{},
+		reverts:    map[string]*time.Timer{},
+	}
+	logrus.SetLevel(s.level)
+	bridge(logrus.StandardLogger())
+	return s
+}
+
+// DefaultLevel returns the level configured through LOG_LEVEL, or info.
+func DefaultLevel() logrus.Level {
+	level, err := logrus.ParseLevel(config.String("LOG_LEVEL", "info"))
+	if err != nil {
+		return logrus.InfoLevel
+	}
+	return level
+}
+
+func register(logger *logrus.Logger) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.loggers = append(state.loggers, logger)
+	logger.SetLevel(state.level)
+}
+
+// Component returns the logger for one part of the service (see the Component*
+// constants). It logs at the global level unless SetComponentLevel overrides it.
+func Component(name string) *logrus.Entry {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	logger, ok := state.components[name]
+	if !ok {
+		logger = newLogger()
+		logger.SetLevel(state.levelFor(name))
+		state.components[name] = logger
+	}
+	return logger.WithField("component", name)
+}
+
+// Leveler returns a slog.Leveler that follows the level of the given component,
+// or the global level for an empty name, for use with slog handlers.
+func Leveler(component string) slog.Leveler {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	levelVar, ok := state.slogLevels[component]
+	if !ok {
+		levelVar = &slog.LevelVar{}
+		levelVar.Set(toSlogLevel(state.levelFor(component)))
+		state.slogLevels[component] = levelVar
+	}
+	return levelVar
+}
+
+// SetLevel changes the global level of the logrus and slog loggers.
+func SetLevel(level logrus.Level) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.stopRevert("")
+	state.level = level
+	state.apply()
+}
+
+// GetLevel returns the current global log level.
+func GetLevel() logrus.Level {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	return state.level
+}
+
+// SetComponentLevel overrides the level of a single component.
+func SetComponentLevel(component string, level logrus.Level) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.stopRevert(component)
+	state.overrides[component] = level
+	state.apply()
+}
+
+// ResetComponentLevel removes a component override so it follows the global level again.
+func ResetComponentLevel(component string) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.stopRevert(component)
+	delete(state.overrides, component)
+	state.apply()
+}
+
+// ResetLevels restores LOG_LEVEL and clears every component override.
+func ResetLevels() {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	for component := range state.reverts {
+		state.stopRevert(component)
+	}
+	state.level = DefaultLevel()
+	state.overrides = map[string]logrus.Level{}
+	state.apply()
+}
+
+// SetLevelTemporarily changes the level of a component (or the global level for
+// an empty name) and drops back to the previous setting after revertAfter.
+func SetLevelTemporarily(component string, level logrus.Level, revertAfter time.Duration) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.stopRevert(component)
+
+	var revert func()
+	if component == "" {
+		previous := state.level
+		state.level = level
+		revert = func() { state.level = previous }
+	} else {
+		previous, overridden := state.overrides[component]
+		state.overrides[component] = level
+		revert = func() {
+			if overridden {
+				state.overrides[component] = previous
+			} else {
+				delete(state.overrides, component)
+			}
+		}
+	}
+	state.apply()
+
+	var timer *time.Timer
+	timer = time.AfterFunc(revertAfter, func() {
+		state.mu.Lock()
+		defer state.mu.Unlock()
+		// A newer change replaced this one in the meantime.
+		if state.reverts[component] != timer {
+			return
+		}
+		delete(state.reverts, component)
+		revert()
+		state.apply()
+		logrus.WithField("component", component).Info("Log level reverted")
+	})
+	state.reverts[component] = timer
+}
+
+// Levels describes the current global level and component overrides.
+type Levels struct {
+	Level      string            `json:"level"`
+	Components map[string]string `json:"components,omitempty"`
+	Reverting  []string          `json:"reverting,omitempty"`
+}
+
+// GetLevels returns the current levels, e.g. for the admin API.
+func GetLevels() Levels {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	levels := Levels{Level: state.level.String(), Components: map[string]string{}}
+	for component, level := range state.overrides {
+		levels.Components[component] = level.String()
+	}
+	for component := range state.reverts {
+		if component == "" {
+			component = "global"
+		}
+		levels.Reverting = append(levels.Reverting, component)
+	}
+	sort.Strings(levels.Reverting)
+	return levels
+}
+
+// levelFor must be called with mu held.
+func (s *levelState) levelFor(component string) logrus.Level {
+	if level, ok := s.overrides[component]; ok {
+		return level
+	}
+	return s.level
+}
+
+// stopRevert must be called with mu held.
+func (s *levelState) stopRevert(component string) {
+	if timer, ok := s.reverts[component]; ok {
+		timer.Stop()
+		delete(s.reverts, component)
+	}
+}
+
+// apply pushes the levels to every logger. It must be called with mu held.
+func (s *levelState) apply() {
+	for _, logger := range s.loggers {
+		logger.SetLevel(s.level)
+	}
+	for component, logger := range s.components {
+		logger.SetLevel(s.levelFor(component))
+	}
+	for component, levelVar := range s.slogLevels {
+		levelVar.Set(toSlogLevel(s.levelFor(component)))
+	}
+}
+
+func toSlogLevel(level logrus.Level) slog.Level {
+	switch level {
+	case logrus.TraceLevel:
+		return slog.LevelDebug - 4
+	case logrus.DebugLevel:
+		return slog.LevelDebug
+	case logrus.InfoLevel:
+		return slog.LevelInfo
+	case logrus.WarnLevel:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+// LevelHandler drops slog records below the level reported by its Leveler,
+// so slog loggers follow the runtime log level.
+type LevelHandler struct {
+	level   slog.Leveler
+	handler slog.Handler
+}
+
+// NewLevelHandler wraps handler with a level filter.
+func NewLevelHandler(level slog.Leveler, handler slog.Handler) *LevelHandler {
+	return &LevelHandler{level: level, handler: handler}
+}
+
+func (h *LevelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level() && h.handler.Enabled(ctx, level)
+}
+
+func (h *LevelHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.handler.Handle(ctx, record)
+}
+
+func (h *LevelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return NewLevelHandler(h.level, h.handler.WithAttrs(attrs))
+}
+
+func (h *LevelHandler) WithGroup(name string) slog.Handler {
+	return NewLevelHandler(h.level, h.handler.WithGroup(name))
+}
@@ -2,16 +2,20 @@ package log
 
 import (
 	"github.com/sirupsen/logrus"
-	"os"
-	"time"
 )
 
-func InitLogger() *logrus.Logger {
+// InitLogger tags every log record with the service name and returns a logrus
+// logger for code that has not moved to Logger yet. Its entries, like those of
+// the standard logrus logger, go through the same pipeline as slog records.
+func InitLogger(service string) *logrus.Logger {
+	SetServiceName(service)
+	logger := newLogger()
+	register(logger)
+	return logger
+}
+
+func newLogger() *logrus.Logger {
 	logger := logrus.New()
-	logger.SetFormatter(&logrus.JSONFormatter{
-		TimestampFormat: time.RFC3339,
-	})
-	logger.SetOutput(os.Stdout)
-	logger.SetLevel(logrus.InfoLevel)
+	bridge(logger)
 	return logger
 }
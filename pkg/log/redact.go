@@ -0,0 +1,75 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+
+	"SimpleMicroserviceProject/pkg/redact"
+)
+
+// RedactHandler masks sensitive attributes and values before a record reaches
+// any output, so neither stdout nor the OpenTelemetry log pipeline sees them.
+type RedactHandler struct {
+	redactor *redact.Redactor
+	handler  slog.Handler
+}
+
+// NewRedactHandler wraps handler with redaction.
+func NewRedactHandler(redactor *redact.Redactor, handler slog.Handler) *RedactHandler {
+	return &RedactHandler{redactor: redactor, handler: handler}
+}
+
+func (h *RedactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *RedactHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, h.redactor.Text(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		if attr, keep := h.redactAttr(attr); keep {
+			redacted.AddAttrs(attr)
+		}
+		return true
+	})
+	return h.handler.Handle(ctx, redacted)
+}
+
+func (h *RedactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, 0, len(attrs))
+	for _, attr := range attrs {
+		if attr, keep := h.redactAttr(attr); keep {
+			redacted = append(redacted, attr)
+		}
+	}
+	return NewRedactHandler(h.redactor, h.handler.WithAttrs(redacted))
+}
+
+func (h *RedactHandler) WithGroup(name string) slog.Handler {
+	return NewRedactHandler(h.redactor, h.handler.WithGroup(name))
+}
+
+// redactAttr returns the masked attribute, or false when it has to be dropped.
+func (h *RedactHandler) redactAttr(attr slog.Attr) (slog.Attr, bool) {
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindGroup:
+		var group []any
+		for _, member := range value.Group() {
+			if member, keep := h.redactAttr(member); keep {
+				group = append(group, member)
+			}
+		}
+		return slog.Group(attr.Key, group...), true
+	case slog.KindString:
+		s, keep := h.redactor.String(attr.Key, value.String())
+		return slog.String(attr.Key, s), keep
+	case slog.KindAny:
+		v, keep := h.redactor.Value(attr.Key, value.Any())
+		return slog.Any(attr.Key, v), keep
+	}
+	if _, ok := h.redactor.KeyMode(attr.Key); ok {
+		v, keep := h.redactor.Value(attr.Key, value.Any())
+		return slog.Any(attr.Key, v), keep
+	}
+	return attr, true
+}
@@ -0,0 +1,59 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"SimpleMicroserviceProject/pkg/redact"
+
+	"github.com/sirupsen/logrus"
+)
+
+// captureStdout returns what fn writes to stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = original }()
+
+	var out bytes.Buffer
+	copied := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(&out, r)
+		close(copied)
+	}()
+	fn()
+	_ = w.Close()
+	<-copied
+	return out.String()
+}
+
+func TestStdoutHasNoPII(t *testing.T) {
+	output := captureStdout(t, func() {
+		// As HandleUser logs a new user.
+		Logger().Info("Received new user", "result", logrus.Fields{
+			"userID": 42,
+			"email":  "jane.doe@example.com",
+			"client": "10.0.0.7:52311",
+		})
+		Component(ComponentHTTP).WithFields(logrus.Fields{
+			"email":         "jane.doe@example.com",
+			"authorization": "Bearer abcdef123456",
+		}).Info("Payment for card 4111 1111 1111 1111 accepted")
+	})
+
+	if !strings.Contains(output, "Received new user") || !strings.Contains(output, "Payment for card") {
+		t.Fatalf("records missing from stdout: %s", output)
+	}
+	if !strings.Contains(output, "j***@example.com") {
+		t.Errorf("email not partially masked: %s", output)
+	}
+	redact.AssertNoPII(t, output)
+}
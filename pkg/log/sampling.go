@@ -0,0 +1,137 @@
+package log
+
+import (
+	"context"
+	"hash/fnv"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"SimpleMicroserviceProject/pkg/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const meterName = "SimpleMicroserviceProject/pkg/log"
+
+// samplingBuckets is the number of counters messages are hashed into.
+// Messages sharing a bucket share their budget, which is fine for sampling.
+const samplingBuckets = 4096
+
+// SamplingConfig limits how often the same message is logged.
+type SamplingConfig struct {
+	// Initial records of each level and message are kept per Interval.
+	// Zero disables sampling.
+	Initial int
+	// Thereafter every Thereafter-th record is kept once Initial is reached.
+	// Zero drops them all.
+	Thereafter int
+	Interval   time.Duration
+	// KeepSampledTraces keeps every record of a sampled trace. With all
+	// traces sampled, this leaves only records outside requests sampled.
+	KeepSampledTraces bool
+}
+
+// SamplingConfigFromEnv reads LOG_SAMPLING_INITIAL (0, disabled),
+// LOG_SAMPLING_THEREAFTER (100), LOG_SAMPLING_INTERVAL (1s) and
+// LOG_SAMPLING_KEEP_SAMPLED_TRACES (true).
+func SamplingConfigFromEnv() SamplingConfig {
+	return SamplingConfig{
+		Initial:           config.Int("LOG_SAMPLING_INITIAL", 0),
+		Thereafter:        config.Int("LOG_SAMPLING_THEREAFTER", 100),
+		Interval:          config.Duration("LOG_SAMPLING_INTERVAL", time.Second),
+		KeepSampledTraces: config.Bool("LOG_SAMPLING_KEEP_SAMPLED_TRACES", true),
+	}
+}
+
+// Enabled reports whether records are sampled.
+func (c SamplingConfig) Enabled() bool {
+	return c.Initial > 0 && c.Interval > 0
+}
+
+type samplingCounter struct {
+	mu      sync.Mutex
+	resetAt time.Time
+	count   uint64
+}
+
+// inc counts a record and returns how many were seen in the current interval.
+func (c *samplingCounter) inc(now time.Time, interval time.Duration) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now.After(c.resetAt) {
+		c.resetAt = now.Add(interval)
+		c.count = 0
+	}
+	c.count++
+	return c.count
+}
+
+// SamplingHandler drops repetitive records. Records at error level and above
+// are always kept, and so are records of sampled traces if the config says so.
+type SamplingHandler struct {
+	cfg      SamplingConfig
+	counters *[samplingBuckets]samplingCounter
+	dropped  metric.Int64Counter
+	handler  slog.Handler
+}
+
+// NewSamplingHandler wraps handler with sampling. It returns handler itself
+// when sampling is disabled.
+func NewSamplingHandler(cfg SamplingConfig, handler slog.Handler) slog.Handler {
+	if !cfg.Enabled() {
+		return handler
+	}
+	dropped, err := otel.Meter(meterName).Int64Counter("log.records.dropped",
+		metric.WithDescription("The number of log records dropped by sampling"),
+		metric.WithUnit("{record}"))
+	if err != nil {
+		dropped = nil
+	}
+	return &SamplingHandler{
+		cfg:      cfg,
+		counters: &[samplingBuckets]samplingCounter{},
+		dropped:  dropped,
+		handler:  handler,
+	}
+}
+
+func (h *SamplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *SamplingHandler) Handle(ctx context.Context, record slog.Record) error {
+	if record.Level >= slog.LevelError ||
+		(h.cfg.KeepSampledTraces && trace.SpanContextFromContext(ctx).IsSampled()) {
+		return h.handler.Handle(ctx, record)
+	}
+
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte{byte(record.Level)})
+	_, _ = hash.Write([]byte(record.Message))
+	counter := &h.counters[hash.Sum32()%samplingBuckets]
+
+	n := counter.inc(record.Time, h.cfg.Interval)
+	if n <= uint64(h.cfg.Initial) ||
+		(h.cfg.Thereafter > 0 && (n-uint64(h.cfg.Initial))%uint64(h.cfg.Thereafter) == 0) {
+		return h.handler.Handle(ctx, record)
+	}
+
+	if h.dropped != nil {
+		h.dropped.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("level", strings.ToLower(fromSlogLevel(record.Level).String()))))
+	}
+	return nil
+}
+
+func (h *SamplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &SamplingHandler{cfg: h.cfg, counters: h.counters, dropped: h.dropped, handler: h.handler.WithAttrs(attrs)}
+}
+
+func (h *SamplingHandler) WithGroup(name string) slog.Handler {
+	return &SamplingHandler{cfg: h.cfg, counters: h.counters, dropped: h.dropped, handler: h.handler.WithGroup(name)}
+}
@@ -0,0 +1,39 @@
+package log
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"SimpleMicroserviceProject/pkg/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// HandleSignals changes the log level on signals until ctx is done:
+// SIGUSR1 switches to debug for LOG_DEBUG_DURATION (10m by default),
+// SIGHUP restores LOG_LEVEL and clears component overrides.
+func HandleSignals(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case sig := <-signals:
+			switch sig {
+			case syscall.SIGUSR1:
+				duration := config.Duration("LOG_DEBUG_DURATION", 10*time.Minute)
+				SetLevelTemporarily("", logrus.DebugLevel, duration)
+				logrus.WithField("revert_after", duration.String()).Info("Debug logging enabled by SIGUSR1")
+			case syscall.SIGHUP:
+				ResetLevels()
+				logrus.WithField("level", GetLevel().String()).Info("Log levels reset by SIGHUP")
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
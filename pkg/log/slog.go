@@ -0,0 +1,219 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"SimpleMicroserviceProject/pkg/config"
+	"SimpleMicroserviceProject/pkg/redact"
+	"SimpleMicroserviceProject/pkg/requestid"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const scopeName = "SimpleMicroserviceProject/pkg/log"
+
+// serviceName is added to every record once SetServiceName was called.
+var serviceName atomic.Value
+
+// pipeline is the handler every record ends up in, whether it was logged
+// through slog or logrus: it samples repetitive records, redacts sensitive
+// values, writes JSON to stdout and forwards the record to the OpenTelemetry
+// log provider. It does not filter by level; the loggers in front of it do.
+var pipeline slog.Handler = NewContextHandler(NewSamplingHandler(SamplingConfigFromEnv(),
+	NewRedactHandler(redact.Default, NewFanoutHandler(
+		newStdoutHandler(config.String("LOG_FORMAT", "json")),
+		// The bridge looks up the global logger provider lazily, so records logged
+		// before SetupOTelSDK simply go nowhere.
+		otelslog.NewHandler(scopeName),
+	))))
+
+// newStdoutHandler returns the stdout handler for LOG_FORMAT: "json" or "ecs"
+// for Elastic Common Schema.
+func newStdoutHandler(format string) slog.Handler {
+	// Everything is let through, the loggers in front of the pipeline filter.
+	level := slog.LevelDebug - 4
+	if strings.EqualFold(format, "ecs") {
+		return NewECSHandler(stdout{}, level)
+	}
+	return slog.NewJSONHandler(stdout{}, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: replaceAttr,
+	})
+}
+
+// stdout writes to the current os.Stdout rather than the one at startup, so
+// tests can capture the output.
+type stdout struct{}
+
+func (stdout) Write(p []byte) (int, error) {
+	return os.Stdout.Write(p)
+}
+
+// SetServiceName sets the service attribute of every log record.
+func SetServiceName(name string) {
+	serviceName.Store(name)
+}
+
+// Logger returns the service logger, which follows the global log level.
+func Logger() *slog.Logger {
+	return slog.New(NewLevelHandler(Leveler(""), pipeline))
+}
+
+// ComponentLogger returns the slog logger of one part of the service. Like
+// Component, it follows the level set for that component.
+func ComponentLogger(name string) *slog.Logger {
+	return slog.New(NewLevelHandler(Leveler(name), pipeline)).With("component", name)
+}
+
+// replaceAttr keeps the stdout format of the logrus JSON formatter.
+func replaceAttr(groups []string, attr slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return attr
+	}
+	switch attr.Key {
+	case slog.TimeKey:
+		return slog.String(slog.TimeKey, attr.Value.Time().Format(time.RFC3339))
+	case slog.LevelKey:
+		return slog.String(slog.LevelKey, strings.ToLower(fromSlogLevel(attr.Value.Any().(slog.Level)).String()))
+	}
+	return attr
+}
+
+// ContextHandler adds the trace and span ID, the service name and the request
+// ID from the context to every record.
+type ContextHandler struct {
+	handler slog.Handler
+}
+
+// NewContextHandler wraps handler with context enrichment.
+func NewContextHandler(handler slog.Handler) *ContextHandler {
+	return &ContextHandler{handler: handler}
+}
+
+func (h *ContextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *ContextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if name, ok := serviceName.Load().(string); ok {
+		record.AddAttrs(slog.String("service", name))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()),
+			slog.Bool("trace_sampled", spanContext.IsSampled()),
+		)
+	}
+	if id := requestid.FromContext(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	return h.handler.Handle(ctx, record)
+}
+
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return NewContextHandler(h.handler.WithAttrs(attrs))
+}
+
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return NewContextHandler(h.handler.WithGroup(name))
+}
+
+// FanoutHandler passes each record to every handler that accepts its level.
+type FanoutHandler struct {
+	handlers []slog.Handler
+}
+
+// NewFanoutHandler returns a handler writing to all of handlers.
+func NewFanoutHandler(handlers ...slog.Handler) *FanoutHandler {
+	return &FanoutHandler{handlers: handlers}
+}
+
+func (h *FanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *FanoutHandler) Handle(ctx context.Context, record slog.Record) error {
+	var joinedErr error
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, record.Level) {
+			joinedErr = errors.Join(joinedErr, handler.Handle(ctx, record.Clone()))
+		}
+	}
+	return joinedErr
+}
+
+func (h *FanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	return NewFanoutHandler(handlers...)
+}
+
+func (h *FanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithGroup(name)
+	}
+	return NewFanoutHandler(handlers...)
+}
+
+// bridgeHook sends logrus entries through the slog pipeline, so legacy logrus
+// calls get the same fields and reach the same destinations. Level filtering
+// already happened in logrus, which keeps component overrides working.
+type bridgeHook struct{}
+
+// bridge routes a logrus logger into the slog pipeline instead of its own output.
+func bridge(logger *logrus.Logger) {
+	logger.SetOutput(io.Discard)
+	logger.AddHook(bridgeHook{})
+}
+
+func (bridgeHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (bridgeHook) Fire(entry *logrus.Entry) error {
+	ctx := entry.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	record := slog.NewRecord(entry.Time, toSlogLevel(entry.Level), entry.Message, 0)
+	for key, value := range entry.Data {
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		record.AddAttrs(slog.Any(key, value))
+	}
+	return pipeline.Handle(ctx, record)
+}
+
+func fromSlogLevel(level slog.Level) logrus.Level {
+	switch {
+	case level < slog.LevelDebug:
+		return logrus.TraceLevel
+	case level < slog.LevelInfo:
+		return logrus.DebugLevel
+	case level < slog.LevelWarn:
+		return logrus.InfoLevel
+	case level < slog.LevelError:
+		return logrus.WarnLevel
+	default:
+		return logrus.ErrorLevel
+	}
+}
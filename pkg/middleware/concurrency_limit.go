@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"SimpleMicroserviceProject/pkg/limiter"
+
+	"github.com/felixge/httpsnoop"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// WithConcurrencyLimit applies an adaptive concurrency limit to the routes of
+// the given RouteMeta group, or to routes without a group when group is empty.
+// Priority routes are never limited.
+func WithConcurrencyLimit(group string, l *limiter.Limiter) Option {
+	return func(o *options) {
+		if o.limiters == nil {
+			o.limiters = map[string]*limiter.Limiter{}
+		}
+		o.limiters[group] = l
+	}
+}
+
+// concurrencyLimits wraps routes with their group's limiter and exports
+// the limiter state as metrics.
+type concurrencyLimits struct {
+	limiters map[string]*limiter.Limiter
+	limited  metric.Int64Counter
+}
+
+func newConcurrencyLimits(limiters map[string]*limiter.Limiter) *concurrencyLimits {
+	meter := otel.Meter(meterName)
+	c := &concurrencyLimits{limiters: limiters}
+
+	var err error
+	c.limited, err = meter.Int64Counter("http.server.limited_requests",
+		metric.WithDescription("The number of requests rejected by the concurrency limiter"),
+		metric.WithUnit("{request}"))
+	if err != nil {
+		httpLogger.WithError(err).Warn("Failed to create concurrency limit counter")
+	}
+
+	limit, err := meter.Int64ObservableGauge("http.server.concurrency.limit",
+		metric.WithDescription("The current adaptive concurrency limit"),
+		metric.WithUnit("{request}"))
+	if err != nil {
+		httpLogger.WithError(err).Warn("Failed to create concurrency limit gauge")
+		return c
+	}
+	inFlight, err := meter.Int64ObservableGauge("http.server.concurrency.in_flight",
+		metric.WithDescription("The number of requests holding a concurrency slot"),
+		metric.WithUnit("{request}"))
+	if err != nil {
+		httpLogger.WithError(err).Warn("Failed to create concurrency in-flight gauge")
+		return c
+	}
+	rtt, err := meter.Float64ObservableGauge("http.server.concurrency.rtt",
+		metric.WithDescription("The request latency estimates used by the concurrency limiter"),
+		metric.WithUnit("s"))
+	if err != nil {
+		httpLogger.WithError(err).Warn("Failed to create concurrency RTT gauge")
+		return c
+	}
+
+	_, err = meter.RegisterCallback(func(_ context.Context, observer metric.Observer) error {
+		for group, l := range c.limiters {
+			stats := l.Stats()
+			groupAttr := attribute.String("group", group)
+			observer.ObserveInt64(limit, int64(stats.Limit), metric.WithAttributes(groupAttr))
+			observer.ObserveInt64(inFlight, int64(stats.InFlight), metric.WithAttributes(groupAttr))
+			observer.ObserveFloat64(rtt, stats.LastRTT.Seconds(),
+				metric.WithAttributes(groupAttr, attribute.String("estimate", "last")))
+			observer.ObserveFloat64(rtt, stats.AvgRTT.Seconds(),
+				metric.WithAttributes(groupAttr, attribute.String("estimate", "average")))
+		}
+		return nil
+	}, limit, inFlight, rtt)
+	if err != nil {
+		httpLogger.WithError(err).Warn("Failed to register concurrency limit metrics")
+	}
+	return c
+}
+
+func (c *concurrencyLimits) middleware(route RouteMeta, next http.HandlerFunc) http.HandlerFunc {
+	l, ok := c.limiters[route.Group]
+	if !ok {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := l.Acquire()
+		if !ok {
+			if c.limited != nil {
+				c.limited.Add(r.Context(), 1, metric.WithAttributes(
+					attribute.String("http.route", route.Route),
+					attribute.String("group", route.Group),
+				))
+			}
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "too many concurrent requests", http.StatusServiceUnavailable)
+			return
+		}
+
+		m := httpsnoop.CaptureMetrics(next, w, r)
+		if m.Code == http.StatusServiceUnavailable || m.Code == http.StatusGatewayTimeout || r.Context().Err() != nil {
+			token.Dropped()
+			return
+		}
+		token.Release()
+	}
+}
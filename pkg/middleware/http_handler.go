@@ -2,19 +2,28 @@ package middleware
 
 import (
 	"context"
-	"net"
 	"net/http"
 	"time"
 
+	applog "SimpleMicroserviceProject/pkg/log"
+	"SimpleMicroserviceProject/pkg/profiling"
+
 	log "github.com/sirupsen/logrus"
 
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/metric/noop"
 )
 
+var httpLogger = applog.Component(applog.ComponentHTTP)
+
 type RouteMeta struct {
 	Route       string
 	Handler     http.HandlerFunc
 	Description string
+	// Priority routes (health checks, probes) bypass load shedding and concurrency limits.
+	Priority bool
+	// Group selects the concurrency limiter configured with WithConcurrencyLimit.
+	Group string
 }
 
 func GetRouteMeta(route string, handler http.HandlerFunc, description string) RouteMeta {
@@ -25,19 +34,27 @@ func GetRouteMeta(route string, handler http.HandlerFunc, description string) Ro
 	}
 }
 
-func GetHttpServer(ctx context.Context, routeMeta []RouteMeta) *http.Server {
-	server := &http.Server{
-		Addr:              ":8080",
-		BaseContext:       func(_ net.Listener) context.Context { return ctx },
-		ReadHeaderTimeout: 1 * time.Second,  // Timeout for reading request headers
-		ReadTimeout:       10 * time.Second, // Timeout for reading the entire request
-		WriteTimeout:      10 * time.Second, // Timeout for writing responses
-		Handler:           NewHTTPHandler(routeMeta),
+// AsPriority marks the route as critical so it is never shed under load.
+func (m RouteMeta) AsPriority() RouteMeta {
+	m.Priority = true
+	return m
+}
+
+// InGroup assigns the route to a concurrency limit group.
+func (m RouteMeta) InGroup(group string) RouteMeta {
+	m.Group = group
+	return m
+}
+
+func NewHTTPHandler(routeMeta []RouteMeta, opts ...Option) http.Handler {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
 	}
-	return server
+	return newHTTPHandler(routeMeta, o)
 }
 
-func NewHTTPHandler(routeMeta []RouteMeta) http.Handler {
+func newHTTPHandler(routeMeta []RouteMeta, o *options) http.Handler {
 	mux := http.NewServeMux()
 
 	// handleFunc is a replacement for mux.HandleFunc
@@ -48,13 +65,43 @@ func NewHTTPHandler(routeMeta []RouteMeta) http.Handler {
 		mux.Handle(pattern, handler)
 	}
 
+	var limits *concurrencyLimits
+	if len(o.limiters) > 0 {
+		limits = newConcurrencyLimits(o.limiters)
+	}
+	red := newREDMetrics(o.metrics)
+
 	// Register HTTP handlers
 	for _, route := range routeMeta {
-		handleFunc(route.Route, loggingMiddleware(route.Handler))
+		handler := profilingMiddleware(route.Route, loggingMiddleware(route.Handler))
+		if !route.Priority {
+			if limits != nil {
+				handler = limits.middleware(route, handler)
+			}
+			if o.loadShedder != nil {
+				handler = o.loadShedder.middleware(route.Route, handler)
+			}
+		}
+		// Outermost, so shed and limited requests count as errors too.
+		if !red.excluded(route.Route) {
+			handler = red.middleware(route.Route, handler)
+		}
+		handleFunc(route.Route, handler)
 	}
 
-	// Add HTTP instrumentation for the whole server.
-	return otelhttp.NewHandler(mux, "/")
+	// Add HTTP instrumentation for the whole server. Its metrics are disabled,
+	// the per route RED metrics replace them.
+	return otelhttp.NewHandler(identityMiddleware(requestIDMiddleware(deadlineMiddleware(mux))), "/",
+		otelhttp.WithMeterProvider(noop.NewMeterProvider()))
+}
+
+// profilingMiddleware labels CPU profiles of the request with its route and trace.
+func profilingMiddleware(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		profiling.Do(r.Context(), func(ctx context.Context) {
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}, "http.route", route)
+	}
 }
 
 // loggingMiddleware wraps handlers for request logging
@@ -62,14 +109,15 @@ func loggingMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
-		log.WithFields(log.Fields{
+		// The request ID and trace context are added from the context.
+		httpLogger.WithContext(r.Context()).WithFields(log.Fields{
 			"method": r.Method,
 			"path":   r.URL.Path,
 		}).Info("Request started")
 
 		next.ServeHTTP(w, r)
 
-		log.WithFields(log.Fields{
+		httpLogger.WithContext(r.Context()).WithFields(log.Fields{
 			"method":      r.Method,
 			"path":        r.URL.Path,
 			"duration_ms": time.Since(start).Milliseconds(),
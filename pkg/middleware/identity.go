@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type callerIdentityKey struct{}
+
+// CallerIdentityFromContext returns the identity of the peer that authenticated
+// with a client certificate, if any.
+func CallerIdentityFromContext(ctx context.Context) (string, bool) {
+	identity, ok := ctx.Value(callerIdentityKey{}).(string)
+	return identity, ok
+}
+
+// identityMiddleware maps the verified peer certificate to a caller identity
+// and stores it in the request context.
+func identityMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		identity := certificateIdentity(r.TLS.VerifiedChains[0][0])
+		if identity == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("enduser.id", identity))
+		ctx := context.WithValue(r.Context(), callerIdentityKey{}, identity)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// certificateIdentity prefers a URI SAN (e.g. a SPIFFE ID), then a DNS SAN,
+// then the subject common name.
+func certificateIdentity(cert *x509.Certificate) string {
+	if len(cert.URIs) > 0 {
+		return cert.URIs[0].String()
+	}
+	if len(cert.DNSNames) > 0 {
+		return cert.DNSNames[0]
+	}
+	return cert.Subject.CommonName
+}
@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"SimpleMicroserviceProject/pkg/config"
+	"SimpleMicroserviceProject/pkg/telemetry"
+
+	"github.com/felixge/httpsnoop"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// MetricsConfig tunes the RED (rate, errors, duration) metrics recorded for every route.
+type MetricsConfig struct {
+	// DurationBuckets are the histogram boundaries of http.server.request.duration in seconds.
+	DurationBuckets []float64
+	// SizeBuckets are the histogram boundaries of the body sizes in bytes.
+	SizeBuckets []float64
+	// ExcludeRoutes lists route patterns without metrics, e.g. health checks.
+	ExcludeRoutes []string
+}
+
+// DefaultMetricsConfig uses the bucket boundaries recommended by the OTel HTTP
+// semantic conventions and records every route.
+func DefaultMetricsConfig() MetricsConfig {
+	return MetricsConfig{
+		DurationBuckets: []float64{0.005, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10},
+		SizeBuckets:     []float64{0, 100, 1000, 10000, 100000, 1000000, 10000000},
+	}
+}
+
+// MetricsConfigFromEnv reads HTTP_METRICS_DURATION_BUCKETS, HTTP_METRICS_SIZE_BUCKETS
+// and HTTP_METRICS_EXCLUDE_ROUTES (the health and probe routes by default).
+func MetricsConfigFromEnv() MetricsConfig {
+	cfg := DefaultMetricsConfig()
+	cfg.DurationBuckets = floatList("HTTP_METRICS_DURATION_BUCKETS", cfg.DurationBuckets)
+	cfg.SizeBuckets = floatList("HTTP_METRICS_SIZE_BUCKETS", cfg.SizeBuckets)
+	cfg.ExcludeRoutes = config.List("HTTP_METRICS_EXCLUDE_ROUTES", []string{"/health", "/livez", "/readyz", "/startupz"})
+	return cfg
+}
+
+// floatList parses a comma separated list of numbers, falling back to def
+// when any of them is invalid.
+func floatList(key string, def []float64) []float64 {
+	items := config.List(key, nil)
+	if items == nil {
+		return def
+	}
+	values := make([]float64, 0, len(items))
+	for _, item := range items {
+		value, err := strconv.ParseFloat(item, 64)
+		if err != nil {
+			httpLogger.WithField("key", key).WithError(err).Warn("Invalid histogram bucket, using defaults")
+			return def
+		}
+		values = append(values, value)
+	}
+	slices.Sort(values)
+	return values
+}
+
+// WithMetrics replaces the default RED metrics configuration.
+func WithMetrics(cfg MetricsConfig) Option {
+	return func(o *options) {
+		o.metrics = cfg
+	}
+}
+
+// redMetrics records the OTel HTTP server metrics per route.
+type redMetrics struct {
+	exclude      []string
+	duration     metric.Float64Histogram
+	active       metric.Int64UpDownCounter
+	requestSize  metric.Int64Histogram
+	responseSize metric.Int64Histogram
+}
+
+func newREDMetrics(cfg MetricsConfig) *redMetrics {
+	meter := otel.Meter(meterName)
+	m := &redMetrics{exclude: cfg.ExcludeRoutes}
+
+	var err error
+	m.duration, err = meter.Float64Histogram("http.server.request.duration",
+		metric.WithDescription("Duration of HTTP server requests"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(cfg.DurationBuckets...))
+	if err != nil {
+		httpLogger.WithError(err).Warn("Failed to create request duration histogram")
+	}
+	m.active, err = meter.Int64UpDownCounter("http.server.active_requests",
+		metric.WithDescription("Number of active HTTP server requests"),
+		metric.WithUnit("{request}"))
+	if err != nil {
+		httpLogger.WithError(err).Warn("Failed to create active requests counter")
+	}
+	m.requestSize, err = meter.Int64Histogram("http.server.request.body.size",
+		metric.WithDescription("Size of HTTP server request bodies"),
+		metric.WithUnit("By"),
+		metric.WithExplicitBucketBoundaries(cfg.SizeBuckets...))
+	if err != nil {
+		httpLogger.WithError(err).Warn("Failed to create request size histogram")
+	}
+	m.responseSize, err = meter.Int64Histogram("http.server.response.body.size",
+		metric.WithDescription("Size of HTTP server response bodies"),
+		metric.WithUnit("By"),
+		metric.WithExplicitBucketBoundaries(cfg.SizeBuckets...))
+	if err != nil {
+		httpLogger.WithError(err).Warn("Failed to create response size histogram")
+	}
+	return m
+}
+
+// excluded reports whether route is configured without metrics.
+func (m *redMetrics) excluded(route string) bool {
+	return slices.Contains(m.exclude, route)
+}
+
+func (m *redMetrics) middleware(route string, next http.Handler) http.HandlerFunc {
+	routeAttr := attribute.String("http.route", route)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		methodAttr := attribute.String("http.request.method", requestMethod(r.Method))
+
+		if m.active != nil {
+			activeCtx := telemetry.WithoutExemplar(ctx)
+			activeAttrs := metric.WithAttributes(routeAttr, methodAttr)
+			m.active.Add(activeCtx, 1, activeAttrs)
+			defer m.active.Add(activeCtx, -1, activeAttrs)
+		}
+
+		body := &countingReader{ReadCloser: r.Body}
+		if r.Body != nil && r.Body != http.NoBody {
+			r.Body = body
+		}
+		start := time.Now()
+		captured := httpsnoop.CaptureMetricsFn(w, func(w http.ResponseWriter) {
+			next.ServeHTTP(w, r)
+		})
+
+		attrs := []attribute.KeyValue{
+			routeAttr,
+			methodAttr,
+			attribute.String("http.response.status_class", strconv.Itoa(captured.Code/100)+"xx"),
+		}
+		if captured.Code >= http.StatusInternalServerError {
+			attrs = append(attrs, attribute.String("error.type", strconv.Itoa(captured.Code)))
+		}
+		recordAttrs := metric.WithAttributes(attrs...)
+
+		if m.duration != nil {
+			m.duration.Record(ctx, time.Since(start).Seconds(), recordAttrs)
+		}
+		if m.requestSize != nil {
+			m.requestSize.Record(ctx, body.n.Load(), recordAttrs)
+		}
+		if m.responseSize != nil {
+			m.responseSize.Record(ctx, captured.Written, recordAttrs)
+		}
+	}
+}
+
+// requestMethod maps unknown methods to _OTHER, as the semantic conventions
+// require, so that arbitrary methods cannot blow up the cardinality.
+func requestMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "_OTHER"
+}
+
+// countingReader counts the bytes of the request body read by the handler.
+type countingReader struct {
+	io.ReadCloser
+	n atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
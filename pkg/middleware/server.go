@@ -0,0 +1,230 @@
+package middleware
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io/fs"
+	stdlog "log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"SimpleMicroserviceProject/pkg/limiter"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Server wraps http.Server so that the listener configured through Option
+// (TCP address, Unix socket or a caller supplied net.Listener) is used when serving.
+type Server struct {
+	*http.Server
+	network  string
+	listener net.Listener
+}
+
+// Option configures the server built by GetHttpServer.
+type Option func(*options)
+
+type options struct {
+	network           string
+	addr              string
+	listener          net.Listener
+	readHeaderTimeout time.Duration
+	readTimeout       time.Duration
+	writeTimeout      time.Duration
+	idleTimeout       time.Duration
+	maxHeaderBytes    int
+	tlsConfig         *tls.Config
+	errorLog          *stdlog.Logger
+	connStateHooks    []func(net.Conn, http.ConnState)
+	handler           http.Handler
+	loadShedder       *loadShedder
+	limiters          map[string]*limiter.Limiter
+	metrics           MetricsConfig
+}
+
+func defaultOptions() *options {
+	return &options{
+		network:           "tcp",
+		addr:              ":8080",
+		readHeaderTimeout: 1 * time.Second,  // Timeout for reading request headers
+		readTimeout:       10 * time.Second, // Timeout for reading the entire request
+		writeTimeout:      10 * time.Second, // Timeout for writing responses
+		metrics:           DefaultMetricsConfig(),
+	}
+}
+
+// WithAddr sets the TCP address to listen on, e.g. ":8080" or ":0" for a random port.
+func WithAddr(addr string) Option {
+	return func(o *options) {
+		o.network = "tcp"
+		o.addr = addr
+	}
+}
+
+// WithUnixSocket makes the server listen on a Unix domain socket at path.
+func WithUnixSocket(path string) Option {
+	return func(o *options) {
+		o.network = "unix"
+		o.addr = path
+	}
+}
+
+// WithListener serves on an already opened listener instead of creating one.
+func WithListener(listener net.Listener) Option {
+	return func(o *options) {
+		o.listener = listener
+	}
+}
+
+// WithReadHeaderTimeout sets the timeout for reading request headers.
+func WithReadHeaderTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.readHeaderTimeout = timeout
+	}
+}
+
+// WithReadTimeout sets the timeout for reading the entire request.
+func WithReadTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.readTimeout = timeout
+	}
+}
+
+// WithWriteTimeout sets the timeout for writing responses.
+func WithWriteTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.writeTimeout = timeout
+	}
+}
+
+// WithIdleTimeout sets how long keep-alive connections may stay idle.
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.idleTimeout = timeout
+	}
+}
+
+// WithMaxHeaderBytes limits the size of request headers.
+func WithMaxHeaderBytes(n int) Option {
+	return func(o *options) {
+		o.maxHeaderBytes = n
+	}
+}
+
+// WithTLS serves HTTPS using the given config, typically one from
+// tlsconfig.Reloader.ServerConfig so rotated certificates are picked up.
+func WithTLS(cfg *tls.Config) Option {
+	return func(o *options) {
+		o.tlsConfig = cfg
+	}
+}
+
+// WithErrorLog bridges the server's internal error log (TLS handshake errors,
+// panics in handlers, ...) into the given logrus logger.
+func WithErrorLog(logger *log.Logger) Option {
+	return func(o *options) {
+		o.errorLog = stdlog.New(logger.WriterLevel(log.ErrorLevel), "", 0)
+	}
+}
+
+// WithConnState registers a hook called whenever a client connection changes state.
+// It can be given multiple times; hooks run in registration order.
+func WithConnState(hook func(net.Conn, http.ConnState)) Option {
+	return func(o *options) {
+		o.connStateHooks = append(o.connStateHooks, hook)
+	}
+}
+
+// WithHandler serves the given handler instead of the instrumented route handler,
+// e.g. for servers whose routes are not part of the public API.
+func WithHandler(handler http.Handler) Option {
+	return func(o *options) {
+		o.handler = handler
+	}
+}
+
+// GetHttpServer builds the service's HTTP server for the given routes.
+// Without options it listens on :8080 with the default timeouts.
+func GetHttpServer(ctx context.Context, routeMeta []RouteMeta, opts ...Option) *Server {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	server := &http.Server{
+		Addr:              o.addr,
+		BaseContext:       func(_ net.Listener) context.Context { return ctx },
+		ReadHeaderTimeout: o.readHeaderTimeout,
+		ReadTimeout:       o.readTimeout,
+		WriteTimeout:      o.writeTimeout,
+		IdleTimeout:       o.idleTimeout,
+		MaxHeaderBytes:    o.maxHeaderBytes,
+		ErrorLog:          o.errorLog,
+		TLSConfig:         o.tlsConfig,
+		Handler:           o.handler,
+	}
+	if server.Handler == nil {
+		server.Handler = newHTTPHandler(routeMeta, o)
+	}
+	if len(o.connStateHooks) > 0 {
+		hooks := o.connStateHooks
+		server.ConnState = func(conn net.Conn, state http.ConnState) {
+			for _, hook := range hooks {
+				hook(conn, state)
+			}
+		}
+	}
+
+	return &Server{
+		Server:   server,
+		network:  o.network,
+		listener: o.listener,
+	}
+}
+
+// Listen opens the configured listener without serving on it yet,
+// so callers can learn the bound address (e.g. when listening on ":0").
+func (s *Server) Listen() (net.Listener, error) {
+	if s.listener != nil {
+		return s.listener, nil
+	}
+
+	if s.network == "unix" {
+		// Remove a stale socket left behind by a previous run.
+		if err := os.Remove(s.Addr); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	listener, err := net.Listen(s.network, s.Addr)
+	if err != nil {
+		return nil, err
+	}
+	s.listener = listener
+	return listener, nil
+}
+
+// ListenAddr returns the address the server is bound to, or nil before Listen.
+func (s *Server) ListenAddr() net.Addr {
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// ListenAndServe listens on the configured address and serves requests,
+// over TLS when WithTLS was given.
+func (s *Server) ListenAndServe() error {
+	listener, err := s.Listen()
+	if err != nil {
+		return err
+	}
+	if s.TLSConfig != nil {
+		// Certificates come from TLSConfig, so no files are passed here.
+		return s.ServeTLS(listener, "", "")
+	}
+	return s.Serve(listener)
+}
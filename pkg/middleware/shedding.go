@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"SimpleMicroserviceProject/pkg/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const meterName = "SimpleMicroserviceProject/pkg/middleware"
+
+// LoadShedderConfig sets the thresholds above which requests are rejected.
+type LoadShedderConfig struct {
+	// QueueDepth and QueueCapacity describe the worker queue the handlers feed,
+	// e.g. len(GetOrderChannel()) and cap(GetOrderChannel()).
+	QueueDepth    func() int
+	QueueCapacity int
+	// MaxQueueUtilization is the fraction of the queue (0-1] that may be used
+	// before requests are shed.
+	MaxQueueUtilization float64
+	// MaxInFlight limits concurrent non-priority requests. Zero disables the limit.
+	MaxInFlight int64
+	// RetryAfter is sent to shed clients in the Retry-After header.
+	RetryAfter time.Duration
+}
+
+// LoadShedderConfigFromEnv reads the thresholds from the SHED_* environment variables.
+// The queue has to be set by the service.
+func LoadShedderConfigFromEnv() LoadShedderConfig {
+	return LoadShedderConfig{
+		MaxQueueUtilization: config.Float("SHED_QUEUE_UTILIZATION", 0.8),
+		MaxInFlight:         int64(config.Int("SHED_MAX_IN_FLIGHT", 0)),
+		RetryAfter:          config.Duration("SHED_RETRY_AFTER", time.Second),
+	}
+}
+
+// WithLoadShedder rejects non-priority requests with 503 and Retry-After
+// while the worker queue or the number of in-flight requests is above its threshold.
+func WithLoadShedder(cfg LoadShedderConfig) Option {
+	return func(o *options) {
+		o.loadShedder = newLoadShedder(cfg)
+	}
+}
+
+type loadShedder struct {
+	config   LoadShedderConfig
+	inFlight atomic.Int64
+	shed     metric.Int64Counter
+}
+
+func newLoadShedder(cfg LoadShedderConfig) *loadShedder {
+	shed, err := otel.Meter(meterName).Int64Counter("http.server.shed_requests",
+		metric.WithDescription("The number of requests rejected by load shedding"),
+		metric.WithUnit("{request}"))
+	if err != nil {
+		httpLogger.WithError(err).Warn("Failed to create load shedding counter")
+	}
+	return &loadShedder{config: cfg, shed: shed}
+}
+
+// reason returns why a request has to be shed, or an empty string to admit it.
+// inFlight includes the request being decided on.
+func (s *loadShedder) reason(inFlight int64) string {
+	if s.config.MaxInFlight > 0 && inFlight > s.config.MaxInFlight {
+		return "in_flight"
+	}
+	if s.config.QueueDepth != nil && s.config.QueueCapacity > 0 && s.config.MaxQueueUtilization > 0 {
+		utilization := float64(s.config.QueueDepth()) / float64(s.config.QueueCapacity)
+		if utilization >= s.config.MaxQueueUtilization {
+			return "queue"
+		}
+	}
+	return ""
+}
+
+func (s *loadShedder) middleware(route string, next http.HandlerFunc) http.HandlerFunc {
+	retryAfter := strconv.Itoa(int(math.Ceil(s.config.RetryAfter.Seconds())))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		inFlight := s.inFlight.Add(1)
+		defer s.inFlight.Add(-1)
+
+		if reason := s.reason(inFlight); reason != "" {
+			if s.shed != nil {
+				s.shed.Add(r.Context(), 1, metric.WithAttributes(
+					attribute.String("http.route", route),
+					attribute.String("reason", reason),
+				))
+			}
+			httpLogger.WithField("path", r.URL.Path).WithField("reason", reason).Debug("Request shed")
+
+			w.Header().Set("Retry-After", retryAfter)
+			http.Error(w, "service overloaded", http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,54 @@
+package profiling
+
+import (
+	"context"
+	"runtime/pprof"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// service is the service label, set once profiling started. Labels are only
+// added while profiling, so they cost nothing otherwise.
+var service atomic.Pointer[string]
+
+func enable(name string) {
+	service.Store(&name)
+	// Goroutines started from here on, like the server and the workers,
+	// inherit the label.
+	pprof.SetGoroutineLabels(pprof.WithLabels(context.Background(), pprof.Labels("service", name)))
+}
+
+// labels returns the service and trace labels for ctx plus the given
+// key/value pairs.
+func labels(ctx context.Context, name string, pairs []string) pprof.LabelSet {
+	all := append([]string{"service", name}, pairs...)
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		all = append(all, "trace_id", spanContext.TraceID().String(), "span_id", spanContext.SpanID().String())
+	}
+	return pprof.Labels(all...)
+}
+
+// Do runs fn with pprof labels for the service, the trace and span of ctx and
+// the given key/value pairs, so CPU profiles can be broken down by request.
+func Do(ctx context.Context, fn func(context.Context), pairs ...string) {
+	name := service.Load()
+	if name == nil {
+		fn(ctx)
+		return
+	}
+	pprof.Do(ctx, labels(ctx, *name, pairs), fn)
+}
+
+// SetGoroutineLabels labels the calling goroutine like Do, until it is
+// labelled again. It suits long running goroutines such as workers, which
+// label each job they pick up. The returned context carries the labels.
+func SetGoroutineLabels(ctx context.Context, pairs ...string) context.Context {
+	name := service.Load()
+	if name == nil {
+		return ctx
+	}
+	ctx = pprof.WithLabels(ctx, labels(ctx, *name, pairs))
+	pprof.SetGoroutineLabels(ctx)
+	return ctx
+}
@@ -0,0 +1,236 @@
+// Package profiling periodically captures pprof profiles of the running
+// service and writes them to a directory or pushes them to a profiling backend.
+package profiling
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"SimpleMicroserviceProject/pkg/config"
+	applog "SimpleMicroserviceProject/pkg/log"
+)
+
+var profilingLogger = applog.Component(applog.ComponentTelemetry)
+
+// Profile types accepted by PROFILING_TYPES.
+const (
+	ProfileCPU       = "cpu"
+	ProfileHeap      = "heap"
+	ProfileGoroutine = "goroutine"
+)
+
+// Config selects what is profiled, how often and where profiles go.
+type Config struct {
+	Enabled bool
+	Types   []string
+	// Interval is the time between two captures.
+	Interval time.Duration
+	// CPUDuration is how long the CPU profile of each capture runs.
+	CPUDuration time.Duration
+	// Dir receives the profiles as <service>-<type>-<time>.pb.gz files.
+	Dir string
+	// Keep is how many files of each type are kept in Dir, older ones are
+	// removed. Zero keeps them all.
+	Keep int
+	// Endpoint is the base URL of a Pyroscope compatible server the
+	// profiles are pushed to, e.g. http://pyroscope:4040.
+	Endpoint string
+}
+
+// ConfigFromEnv reads PROFILING_ENABLED (false), PROFILING_TYPES (cpu,heap,goroutine),
+// PROFILING_INTERVAL (60s), PROFILING_CPU_DURATION (10s), PROFILING_DIR (the temp
+// directory unless an endpoint is set), PROFILING_KEEP (10) and PROFILING_ENDPOINT.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Enabled:     config.Bool("PROFILING_ENABLED", false),
+		Types:       config.List("PROFILING_TYPES", []string{ProfileCPU, ProfileHeap, ProfileGoroutine}),
+		Interval:    config.Duration("PROFILING_INTERVAL", time.Minute),
+		CPUDuration: config.Duration("PROFILING_CPU_DURATION", 10*time.Second),
+		Dir:         config.String("PROFILING_DIR", ""),
+		Keep:        config.Int("PROFILING_KEEP", 10),
+		Endpoint:    config.String("PROFILING_ENDPOINT", ""),
+	}
+	if cfg.Dir == "" && cfg.Endpoint == "" {
+		cfg.Dir = os.TempDir()
+	}
+	return cfg
+}
+
+// Profiler captures profiles until it is stopped.
+type Profiler struct {
+	cfg     Config
+	service string
+	client  *http.Client
+	stop    chan struct{}
+	done    sync.WaitGroup
+}
+
+// Start captures profiles of service every cfg.Interval, starting with the
+// first interval. It also enables the service and trace labels, see Do.
+func Start(cfg Config, service string) *Profiler {
+	var types []string
+	for _, profile := range cfg.Types {
+		switch profile = strings.ToLower(profile); profile {
+		case ProfileCPU, ProfileHeap, ProfileGoroutine:
+			types = append(types, profile)
+		default:
+			profilingLogger.WithField("profile", profile).Warn("Unknown profile type, skipping it")
+		}
+	}
+	cfg.Types = types
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+
+	p := &Profiler{
+		cfg:     cfg,
+		service: service,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		stop:    make(chan struct{}),
+	}
+	enable(service)
+	p.done.Add(1)
+	go p.run()
+	profilingLogger.WithField("types", cfg.Types).WithField("interval", cfg.Interval).Info("Continuous profiling started")
+	return p
+}
+
+// Stop ends profiling after the running capture, or when ctx is done.
+func (p *Profiler) Stop(ctx context.Context) error {
+	close(p.stop)
+	stopped := make(chan struct{})
+	go func() {
+		p.done.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Profiler) run() {
+	defer p.done.Done()
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			for _, profile := range p.cfg.Types {
+				p.capture(profile)
+			}
+		}
+	}
+}
+
+// capture takes one profile and delivers it, logging failures.
+func (p *Profiler) capture(profile string) {
+	logger := profilingLogger.WithField("profile", profile)
+	start := time.Now()
+	var buf bytes.Buffer
+	var err error
+	switch profile {
+	case ProfileCPU:
+		err = p.cpuProfile(&buf)
+	default:
+		err = pprof.Lookup(profile).WriteTo(&buf, 0)
+	}
+	if err != nil {
+		logger.WithError(err).Warn("Failed to capture profile")
+		return
+	}
+
+	if p.cfg.Dir != "" {
+		if err := p.write(profile, start, buf.Bytes()); err != nil {
+			logger.WithError(err).Warn("Failed to write profile")
+		}
+	}
+	if p.cfg.Endpoint != "" {
+		if err := p.push(profile, start, time.Now(), buf.Bytes()); err != nil {
+			logger.WithError(err).Warn("Failed to push profile")
+		}
+	}
+}
+
+// cpuProfile profiles the CPU for CPUDuration, or until the profiler stops.
+// It fails while another CPU profile runs, e.g. one requested from /debug/pprof.
+func (p *Profiler) cpuProfile(buf *bytes.Buffer) error {
+	if err := pprof.StartCPUProfile(buf); err != nil {
+		return err
+	}
+	timer := time.NewTimer(p.cfg.CPUDuration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-p.stop:
+	}
+	pprof.StopCPUProfile()
+	return nil
+}
+
+// write stores a profile in Dir and removes the oldest of its type beyond Keep.
+func (p *Profiler) write(profile string, at time.Time, data []byte) error {
+	prefix := fmt.Sprintf("%s-%s-", p.service, profile)
+	name := prefix + at.UTC().Format("20060102T150405.000Z") + ".pb.gz"
+	if err := os.WriteFile(filepath.Join(p.cfg.Dir, name), data, 0o600); err != nil {
+		return err
+	}
+	if p.cfg.Keep <= 0 {
+		return nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(p.cfg.Dir, prefix+"*.pb.gz"))
+	if err != nil || len(files) <= p.cfg.Keep {
+		return err
+	}
+	// The timestamps have a fixed width, so names sort by time.
+	slices.Sort(files)
+	for _, file := range files[:len(files)-p.cfg.Keep] {
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// push sends a profile to the ingest API of a Pyroscope compatible server,
+// named after the service and labelled with the profile type.
+func (p *Profiler) push(profile string, from, until time.Time, data []byte) error {
+	query := url.Values{
+		"name":    {fmt.Sprintf("%s{service=%s,profile=%s}", p.service, p.service, profile)},
+		"from":    {fmt.Sprint(from.Unix())},
+		"until":   {fmt.Sprint(until.Unix())},
+		"format":  {"pprof"},
+		"spyName": {"gospy"},
+	}
+	endpoint := strings.TrimSuffix(p.cfg.Endpoint, "/") + "/ingest?" + query.Encode()
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package redact
+
+// TB is the part of testing.TB used by the assertions, so this package does
+// not have to import testing.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// AssertNoPII fails t for every email, card number or token found in output,
+// e.g. captured log lines or exported span attributes.
+func AssertNoPII(t TB, output string) {
+	t.Helper()
+	for _, match := range Find(output) {
+		t.Errorf("redact: output contains unredacted value %q", match)
+	}
+}
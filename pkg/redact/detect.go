@@ -0,0 +1,89 @@
+package redact
+
+import (
+	"regexp"
+	"strings"
+)
+
+type kind int
+
+const (
+	kindOther kind = iota
+	kindEmail
+	kindCard
+	kindToken
+)
+
+type detector struct {
+	kind    kind
+	pattern *regexp.Regexp
+	// valid filters out matches of pattern that are not sensitive.
+	valid func(match string) bool
+}
+
+var detectors = []detector{
+	{kind: kindEmail, pattern: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)},
+	// 13 to 19 digits, optionally grouped with spaces or dashes.
+	{kind: kindCard, pattern: regexp.MustCompile(`\b\d(?:[ \-]?\d){12,18}\b`), valid: luhn},
+	{kind: kindToken, pattern: regexp.MustCompile(
+		`\beyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+` + // JWT
+			`|(?i:bearer)\s+[A-Za-z0-9\-._~+/]{8,}=*` +
+			`|\b(?:sk|pk|rk)_(?:live|test)_[A-Za-z0-9]{8,}` + // payment provider keys
+			`|\bgh[pousr]_[A-Za-z0-9]{20,}` +
+			`|\bAKIA[0-9A-Z]{16}\b`)},
+}
+
+// kindOf guesses what a value configured for masking is, to pick the partial format.
+func kindOf(value string) kind {
+	for _, d := range detectors {
+		if match := d.pattern.FindString(value); match == value && (d.valid == nil || d.valid(match)) {
+			return d.kind
+		}
+	}
+	return kindOther
+}
+
+// luhn reports whether the digits pass the Luhn checksum used by card numbers.
+func luhn(number string) bool {
+	digits := onlyDigits(number)
+	if len(digits) < 13 {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+func onlyDigits(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if c >= '0' && c <= '9' {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// Find returns the emails, card numbers and tokens contained in text.
+func Find(text string) []string {
+	var found []string
+	for _, d := range detectors {
+		for _, match := range d.pattern.FindAllString(text, -1) {
+			if d.valid == nil || d.valid(match) {
+				found = append(found, match)
+			}
+		}
+	}
+	return found
+}
@@ -0,0 +1,212 @@
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"SimpleMicroserviceProject/pkg/config"
+)
+
+// Mode is how a sensitive value is masked.
+type Mode string
+
+const (
+	// ModeHash replaces the value with a keyed hash, so equal values can still
+	// be correlated without revealing them.
+	ModeHash Mode = "hash"
+	// ModePartial keeps just enough to recognise the value, e.g. the last four
+	// digits of a card number or the domain of an email address.
+	ModePartial Mode = "partial"
+	// ModeDrop removes the field, or replaces the value inside free text.
+	ModeDrop Mode = "drop"
+)
+
+// Placeholder replaces values that are dropped from free text.
+const Placeholder = "[REDACTED]"
+
+// Config selects what is redacted and how.
+type Config struct {
+	// Keys maps field names (case-insensitive, the last segment of dotted
+	// names also matches) to the mode used for their values.
+	Keys map[string]Mode
+	// PatternMode masks emails, card numbers and tokens found in any string.
+	// Empty disables pattern detection.
+	PatternMode Mode
+	// HashKey keys the hash of ModeHash.
+	HashKey string
+}
+
+// DefaultKeys are redacted unless REDACT_KEYS replaces them.
+func DefaultKeys() map[string]Mode {
+	return map[string]Mode{
+		"password":       ModeDrop,
+		"secret":         ModeDrop,
+		"token":          ModeDrop,
+		"authorization":  ModeDrop,
+		"api_key":        ModeDrop,
+		"cookie":         ModeDrop,
+		"email":          ModePartial,
+		"card_number":    ModePartial,
+		"iban":           ModePartial,
+		"account_number": ModePartial,
+	}
+}
+
+// ConfigFromEnv reads REDACT_KEYS ("email=partial,password=drop"),
+// REDACT_PATTERN_MODE (partial, or "none" to disable) and REDACT_HASH_KEY.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Keys:        DefaultKeys(),
+		PatternMode: Mode(strings.ToLower(config.String("REDACT_PATTERN_MODE", string(ModePartial)))),
+		HashKey:     config.String("REDACT_HASH_KEY", ""),
+	}
+	if cfg.PatternMode == "none" {
+		cfg.PatternMode = ""
+	}
+	if keys := config.List("REDACT_KEYS", nil); keys != nil {
+		cfg.Keys = map[string]Mode{}
+		for _, item := range keys {
+			key, mode, found := strings.Cut(item, "=")
+			if !found {
+				mode = string(ModeDrop)
+			}
+			cfg.Keys[strings.ToLower(strings.TrimSpace(key))] = Mode(strings.ToLower(strings.TrimSpace(mode)))
+		}
+	}
+	return cfg
+}
+
+// Redactor masks sensitive fields and values.
+type Redactor struct {
+	keys        map[string]Mode
+	patternMode Mode
+	hashKey     []byte
+}
+
+// Default is the redactor used by the logging and tracing pipelines.
+var Default = New(ConfigFromEnv())
+
+// New returns a redactor for cfg.
+func New(cfg Config) *Redactor {
+	keys := make(map[string]Mode, len(cfg.Keys))
+	for key, mode := range cfg.Keys {
+		keys[strings.ToLower(key)] = mode
+	}
+	return &Redactor{keys: keys, patternMode: cfg.PatternMode, hashKey: []byte(cfg.HashKey)}
+}
+
+// KeyMode returns the mode configured for a field name.
+func (r *Redactor) KeyMode(key string) (Mode, bool) {
+	key = strings.ToLower(key)
+	if mode, ok := r.keys[key]; ok {
+		return mode, true
+	}
+	if i := strings.LastIndexByte(key, '.'); i >= 0 {
+		mode, ok := r.keys[key[i+1:]]
+		return mode, ok
+	}
+	return "", false
+}
+
+// String redacts a string field. It returns false when the field has to be dropped.
+func (r *Redactor) String(key, value string) (string, bool) {
+	if mode, ok := r.KeyMode(key); ok {
+		if mode == ModeDrop {
+			return "", false
+		}
+		return r.mask(mode, value, kindOf(value)), true
+	}
+	return r.Text(value), true
+}
+
+// Text masks the emails, card numbers and tokens found in free text.
+func (r *Redactor) Text(text string) string {
+	if r.patternMode == "" || text == "" {
+		return text
+	}
+	for _, d := range detectors {
+		text = d.pattern.ReplaceAllStringFunc(text, func(match string) string {
+			if d.valid != nil && !d.valid(match) {
+				return match
+			}
+			if r.patternMode == ModeDrop {
+				return Placeholder
+			}
+			return r.mask(r.patternMode, match, d.kind)
+		})
+	}
+	return text
+}
+
+// Value redacts an arbitrary field value. Strings are masked, maps with string
+// keys are redacted recursively and other values are kept. It returns false
+// when the field has to be dropped.
+func (r *Redactor) Value(key string, value any) (any, bool) {
+	if mode, ok := r.KeyMode(key); ok {
+		if mode == ModeDrop {
+			return nil, false
+		}
+		s := fmt.Sprint(value)
+		return r.mask(mode, s, kindOf(s)), true
+	}
+
+	switch v := value.(type) {
+	case string:
+		return r.Text(v), true
+	case error:
+		return r.Text(v.Error()), true
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return value, true
+	}
+	redacted := make(map[string]any, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		k := iter.Key().String()
+		if v, keep := r.Value(k, iter.Value().Interface()); keep {
+			redacted[k] = v
+		}
+	}
+	return redacted, true
+}
+
+func (r *Redactor) mask(mode Mode, value string, kind kind) string {
+	switch mode {
+	case ModeHash:
+		mac := hmac.New(sha256.New, r.hashKey)
+		mac.Write([]byte(value))
+		return "sha256:" + hex.EncodeToString(mac.Sum(nil))[:16]
+	case ModePartial:
+		return partial(value, kind)
+	default:
+		return Placeholder
+	}
+}
+
+// partial masks all but a recognisable part of value.
+func partial(value string, kind kind) string {
+	switch kind {
+	case kindEmail:
+		local, domain, _ := strings.Cut(value, "@")
+		if len(local) > 1 {
+			local = local[:1]
+		}
+		return local + "***@" + domain
+	case kindCard:
+		digits := onlyDigits(value)
+		return strings.Repeat("*", len(digits)-4) + digits[len(digits)-4:]
+	}
+	// Tokens and unknown values keep their last four characters when long
+	// enough. They are counted in runes so the result stays valid UTF-8.
+	runes := []rune(value)
+	if len(runes) <= 8 {
+		return strings.Repeat("*", len(runes))
+	}
+	return strings.Repeat("*", len(runes)-4) + string(runes[len(runes)-4:])
+}
@@ -0,0 +1,262 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ErrOpen is returned while a circuit breaker rejects calls.
+var ErrOpen = errors.New("resilience: circuit breaker is open")
+
+// State is the state of a circuit breaker.
+type State int
+
+const (
+	// StateClosed lets every call through and records its outcome.
+	StateClosed State = iota
+	// StateOpen rejects calls until OpenDuration has passed.
+	StateOpen
+	// StateHalfOpen lets a few probe calls through to decide whether to close again.
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half_open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerConfig configures a circuit breaker.
+type BreakerConfig struct {
+	// WindowSize is the number of most recent calls the rates are computed over.
+	WindowSize int
+	// MinimumCalls is the number of calls needed before the breaker may open.
+	MinimumCalls int
+	// FailureRateThreshold opens the breaker when this fraction of calls failed.
+	FailureRateThreshold float64
+	// SlowCallDuration marks calls taking longer as slow. Zero disables slow call tracking.
+	SlowCallDuration time.Duration
+	// SlowCallRateThreshold opens the breaker when this fraction of calls was slow.
+	SlowCallRateThreshold float64
+	// OpenDuration is how long the breaker stays open before probing.
+	OpenDuration time.Duration
+	// HalfOpenCalls is the number of probe calls allowed in the half-open state.
+	HalfOpenCalls int
+}
+
+// DefaultBreakerConfig opens after half of the last 20 calls failed or were slower than 2s.
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		WindowSize:            20,
+		MinimumCalls:          10,
+		FailureRateThreshold:  0.5,
+		SlowCallDuration:      2 * time.Second,
+		SlowCallRateThreshold: 0.8,
+		OpenDuration:          10 * time.Second,
+		HalfOpenCalls:         3,
+	}
+}
+
+type outcome struct {
+	failed bool
+	slow   bool
+}
+
+// CircuitBreaker stops calling a dependency once too many calls failed or were
+// slow, so callers fail fast instead of tying up goroutines.
+type CircuitBreaker struct {
+	name   string
+	config BreakerConfig
+
+	mu       sync.Mutex
+	state    State
+	openedAt time.Time
+	window   []outcome
+	next     int
+	// Probe calls let through and completed in the half-open state.
+	probes    int
+	completed int
+	failures  int
+}
+
+// NewCircuitBreaker returns a closed circuit breaker for the named dependency.
+func NewCircuitBreaker(name string, cfg BreakerConfig) *CircuitBreaker {
+	if cfg.WindowSize <= 0 {
+		cfg.WindowSize = DefaultBreakerConfig().WindowSize
+	}
+	if cfg.HalfOpenCalls <= 0 {
+		cfg.HalfOpenCalls = 1
+	}
+	b := &CircuitBreaker{
+		name:   name,
+		config: cfg,
+		window: make([]outcome, 0, cfg.WindowSize),
+	}
+	registerBreaker(b)
+	return b
+}
+
+// Name returns the dependency the breaker protects.
+func (b *CircuitBreaker) Name() string {
+	return b.name
+}
+
+// State returns the current state.
+func (b *CircuitBreaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.expireOpen()
+	return b.state
+}
+
+// Allow reports whether a call may proceed. On success the returned function
+// must be called with the call's result once it completes.
+func (b *CircuitBreaker) Allow(ctx context.Context) (func(err error), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.expireOpen()
+	switch b.state {
+	case StateOpen:
+		breakerMetrics.rejected.Add(ctx, 1, metric.WithAttributes(attribute.String("name", b.name)))
+		return nil, ErrOpen
+	case StateHalfOpen:
+		if b.probes >= b.config.HalfOpenCalls {
+			breakerMetrics.rejected.Add(ctx, 1, metric.WithAttributes(attribute.String("name", b.name)))
+			return nil, ErrOpen
+		}
+		b.probes++
+	}
+
+	state := b.state
+	start := time.Now()
+	return func(err error) {
+		b.record(ctx, state, err != nil, time.Since(start))
+	}, nil
+}
+
+// Execute runs fn if the breaker allows it and records its result.
+func (b *CircuitBreaker) Execute(ctx context.Context, fn func(context.Context) error) error {
+	done, err := b.Allow(ctx)
+	if err != nil {
+		return err
+	}
+	err = fn(ctx)
+	done(CallerError(ctx, err))
+	return err
+}
+
+// CallerError returns the result to record for a call that returned err: nil
+// when the caller canceled the call or its deadline passed, since that says
+// nothing about the dependency's health.
+func CallerError(ctx context.Context, err error) error {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+func (b *CircuitBreaker) record(ctx context.Context, startedIn State, failed bool, duration time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	slow := b.config.SlowCallDuration > 0 && duration > b.config.SlowCallDuration
+	switch b.state {
+	case StateHalfOpen:
+		if startedIn != StateHalfOpen {
+			return
+		}
+		b.completed++
+		if failed || slow {
+			b.failures++
+		}
+		if b.failures > 0 {
+			b.transition(ctx, StateOpen)
+		} else if b.completed >= b.config.HalfOpenCalls {
+			b.transition(ctx, StateClosed)
+		}
+	case StateClosed:
+		b.add(outcome{failed: failed, slow: slow})
+		if b.tripped() {
+			b.transition(ctx, StateOpen)
+		}
+	}
+}
+
+func (b *CircuitBreaker) add(o outcome) {
+	if len(b.window) < b.config.WindowSize {
+		b.window = append(b.window, o)
+		return
+	}
+	b.window[b.next] = o
+	b.next = (b.next + 1) % b.config.WindowSize
+}
+
+// tripped reports whether the failure or slow call rate crossed its threshold.
+func (b *CircuitBreaker) tripped() bool {
+	if len(b.window) < b.config.MinimumCalls || len(b.window) == 0 {
+		return false
+	}
+
+	var failed, slow int
+	for _, o := range b.window {
+		if o.failed {
+			failed++
+		}
+		if o.slow {
+			slow++
+		}
+	}
+	calls := float64(len(b.window))
+	if b.config.FailureRateThreshold > 0 && float64(failed)/calls >= b.config.FailureRateThreshold {
+		return true
+	}
+	return b.config.SlowCallRateThreshold > 0 && float64(slow)/calls >= b.config.SlowCallRateThreshold
+}
+
+// expireOpen moves an open breaker to half-open once OpenDuration has passed.
+func (b *CircuitBreaker) expireOpen() {
+	if b.state == StateOpen && time.Since(b.openedAt) >= b.config.OpenDuration {
+		b.transition(context.Background(), StateHalfOpen)
+	}
+}
+
+func (b *CircuitBreaker) transition(ctx context.Context, to State) {
+	from := b.state
+	if from == to {
+		return
+	}
+
+	b.state = to
+	b.probes, b.completed, b.failures = 0, 0, 0
+	switch to {
+	case StateOpen:
+		b.openedAt = time.Now()
+	case StateClosed:
+		b.window = b.window[:0]
+		b.next = 0
+	}
+
+	breakerMetrics.transitions.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("name", b.name),
+		attribute.String("from", from.String()),
+		attribute.String("to", to.String()),
+	))
+	resilienceLogger.WithContext(ctx).
+		WithField("breaker", b.name).
+		WithField("from", from.String()).
+		WithField("to", to.String()).
+		Warn("Circuit breaker state changed")
+}
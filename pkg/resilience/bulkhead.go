@@ -0,0 +1,80 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ErrBulkheadFull is returned when no slot became free within the wait time.
+var ErrBulkheadFull = errors.New("resilience: bulkhead is full")
+
+// Bulkhead caps the number of concurrent calls to a dependency, so a slow
+// dependency can only tie up its own share of goroutines and worker slots.
+type Bulkhead struct {
+	name    string
+	slots   chan struct{}
+	maxWait time.Duration
+}
+
+// NewBulkhead allows maxConcurrent calls at once. Further calls wait up to
+// maxWait for a slot; zero rejects them immediately.
+func NewBulkhead(name string, maxConcurrent int, maxWait time.Duration) *Bulkhead {
+	b := &Bulkhead{
+		name:    name,
+		slots:   make(chan struct{}, max(maxConcurrent, 1)),
+		maxWait: maxWait,
+	}
+	registerBulkhead(b)
+	return b
+}
+
+// Name returns the dependency the bulkhead protects.
+func (b *Bulkhead) Name() string {
+	return b.name
+}
+
+// InUse returns the number of occupied slots.
+func (b *Bulkhead) InUse() int {
+	return len(b.slots)
+}
+
+// Acquire takes a slot. The returned function releases it.
+func (b *Bulkhead) Acquire(ctx context.Context) (func(), error) {
+	release := func() { <-b.slots }
+
+	select {
+	case b.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	if b.maxWait > 0 {
+		timer := time.NewTimer(b.maxWait)
+		defer timer.Stop()
+		select {
+		case b.slots <- struct{}{}:
+			return release, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	bulkheadMetrics.rejected.Add(ctx, 1, metric.WithAttributes(attribute.String("name", b.name)))
+	resilienceLogger.WithContext(ctx).WithField("bulkhead", b.name).Debug("Bulkhead full")
+	return nil, ErrBulkheadFull
+}
+
+// Execute runs fn while holding a slot.
+func (b *Bulkhead) Execute(ctx context.Context, fn func(context.Context) error) error {
+	release, err := b.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return fn(ctx)
+}
@@ -0,0 +1,92 @@
+package resilience
+
+import (
+	"context"
+	"sync"
+
+	applog "SimpleMicroserviceProject/pkg/log"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+const meterName = "SimpleMicroserviceProject/pkg/resilience"
+
+var resilienceLogger = applog.Component(applog.ComponentResilience)
+
+var (
+	registryMu sync.Mutex
+	breakers   []*CircuitBreaker
+	bulkheads  []*Bulkhead
+)
+
+var breakerMetrics struct {
+	transitions metric.Int64Counter
+	rejected    metric.Int64Counter
+}
+
+var bulkheadMetrics struct {
+	rejected metric.Int64Counter
+}
+
+func init() {
+	meter := otel.Meter(meterName)
+	breakerMetrics.transitions = int64Counter(meter, "resilience.circuit_breaker.transitions",
+		"The number of circuit breaker state changes", "{transition}")
+	breakerMetrics.rejected = int64Counter(meter, "resilience.circuit_breaker.rejected_calls",
+		"The number of calls rejected by an open circuit breaker", "{call}")
+	bulkheadMetrics.rejected = int64Counter(meter, "resilience.bulkhead.rejected_calls",
+		"The number of calls rejected by a full bulkhead", "{call}")
+
+	state, err := meter.Int64ObservableGauge("resilience.circuit_breaker.state",
+		metric.WithDescription("The circuit breaker state: 0 closed, 1 open, 2 half-open"))
+	if err != nil {
+		resilienceLogger.WithError(err).Warn("Failed to create circuit breaker state gauge")
+		return
+	}
+	inUse, err := meter.Int64ObservableGauge("resilience.bulkhead.in_use",
+		metric.WithDescription("The number of occupied bulkhead slots"),
+		metric.WithUnit("{call}"))
+	if err != nil {
+		resilienceLogger.WithError(err).Warn("Failed to create bulkhead gauge")
+		return
+	}
+
+	_, err = meter.RegisterCallback(func(_ context.Context, observer metric.Observer) error {
+		registryMu.Lock()
+		defer registryMu.Unlock()
+		for _, b := range breakers {
+			observer.ObserveInt64(state, int64(b.State()), metric.WithAttributes(attribute.String("name", b.name)))
+		}
+		for _, b := range bulkheads {
+			observer.ObserveInt64(inUse, int64(b.InUse()), metric.WithAttributes(attribute.String("name", b.name)))
+		}
+		return nil
+	}, state, inUse)
+	if err != nil {
+		resilienceLogger.WithError(err).Warn("Failed to register resilience metrics")
+	}
+}
+
+func int64Counter(meter metric.Meter, name, description, unit string) metric.Int64Counter {
+	counter, err := meter.Int64Counter(name, metric.WithDescription(description), metric.WithUnit(unit))
+	if err != nil {
+		resilienceLogger.WithError(err).Warn("Failed to create resilience counter")
+		return noop.Int64Counter{}
+	}
+	return counter
+}
+
+func registerBreaker(b *CircuitBreaker) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	breakers = append(breakers, b)
+}
+
+func registerBulkhead(b *Bulkhead) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	bulkheads = append(bulkheads, b)
+}
@@ -0,0 +1,15 @@
+package telemetry
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithoutExemplar hides the span of ctx from the metrics SDK, so measurements
+// recorded with the returned context get no exemplar. Up/down counters use
+// it: Prometheus exposes them as gauges, which cannot carry exemplars, so the
+// exporter would log an error for the exemplar on every scrape.
+func WithoutExemplar(ctx context.Context) context.Context {
+	return trace.ContextWithSpanContext(ctx, trace.SpanContext{})
+}
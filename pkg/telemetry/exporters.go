@@ -0,0 +1,306 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"SimpleMicroserviceProject/pkg/config"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutlog"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Exporter names accepted by OTEL_TRACES_EXPORTER, OTEL_METRICS_EXPORTER and
+// OTEL_LOGS_EXPORTER. A comma separated list exports to several at once.
+const (
+	ExporterOTLP    = "otlp"
+	ExporterConsole = "console"
+	ExporterFile    = "file"
+	ExporterNone    = "none"
+	// ExporterPrometheus exposes metrics for scraping, see MetricsHandler.
+	// It only applies to OTEL_METRICS_EXPORTER.
+	ExporterPrometheus = "prometheus"
+)
+
+// OTLP protocols accepted by OTEL_EXPORTER_OTLP_PROTOCOL and its per signal variants.
+const (
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http/protobuf"
+)
+
+// signal is one of the three OpenTelemetry signals, named as in the OTEL_* variables.
+type signal string
+
+const (
+	signalTraces  signal = "traces"
+	signalMetrics signal = "metrics"
+	signalLogs    signal = "logs"
+)
+
+// exporters returns the exporters configured for s. Traces default to OTLP
+// and metrics to the console. Logs are not exported by default, since the log
+// pipeline already writes every record to stdout.
+func exporters(s signal) []string {
+	def := ExporterConsole
+	switch s {
+	case signalTraces:
+		def = ExporterOTLP
+	case signalLogs:
+		def = ExporterNone
+	}
+	names := config.List("OTEL_"+strings.ToUpper(string(s))+"_EXPORTER", []string{def})
+	for i, name := range names {
+		names[i] = strings.ToLower(name)
+		// "stdout" is accepted as in older SDKs.
+		if names[i] == "stdout" {
+			names[i] = ExporterConsole
+		}
+	}
+	return names
+}
+
+// protocol returns the OTLP protocol of s, http/protobuf unless configured otherwise.
+func protocol(s signal) string {
+	return strings.ToLower(config.String("OTEL_EXPORTER_OTLP_"+strings.ToUpper(string(s))+"_PROTOCOL",
+		config.String("OTEL_EXPORTER_OTLP_PROTOCOL", ProtocolHTTP)))
+}
+
+// endpointConfigured reports whether the OTLP endpoint of s comes from the environment.
+// Otherwise traces go to the in-cluster Jaeger collector.
+func endpointConfigured(s signal) bool {
+	return config.String("OTEL_EXPORTER_OTLP_"+strings.ToUpper(string(s))+"_ENDPOINT",
+		config.String("OTEL_EXPORTER_OTLP_ENDPOINT", "")) != ""
+}
+
+// openExportFile opens the file the file exporter of s appends to, in
+// OTEL_EXPORTER_FILE_DIR (the temp directory by default).
+func openExportFile(s signal) (*os.File, error) {
+	dir := config.String("OTEL_EXPORTER_FILE_DIR", os.TempDir())
+	path := filepath.Join(dir, string(s)+".jsonl")
+	// #nosec G304 -- the directory is operator configuration.
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+}
+
+// exportWriter returns the writer of a console or file exporter. The closer
+// is nil for the console.
+func exportWriter(s signal, name string) (io.Writer, io.Closer, error) {
+	if name == ExporterConsole {
+		return os.Stdout, nil, nil
+	}
+	file, err := openExportFile(s)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, file, nil
+}
+
+// The closing exporters close the file of a file exporter after shutting it down.
+
+type closingSpanExporter struct {
+	trace.SpanExporter
+	closer io.Closer
+}
+
+func (e closingSpanExporter) Shutdown(ctx context.Context) error {
+	return errors.Join(e.SpanExporter.Shutdown(ctx), e.closer.Close())
+}
+
+type closingMetricExporter struct {
+	metric.Exporter
+	closer io.Closer
+}
+
+func (e closingMetricExporter) Shutdown(ctx context.Context) error {
+	return errors.Join(e.Exporter.Shutdown(ctx), e.closer.Close())
+}
+
+type closingLogExporter struct {
+	log.Exporter
+	closer io.Closer
+}
+
+func (e closingLogExporter) Shutdown(ctx context.Context) error {
+	return errors.Join(e.Exporter.Shutdown(ctx), e.closer.Close())
+}
+
+// newSpanExporters builds the span exporters selected by OTEL_TRACES_EXPORTER.
+// The OTLP exporters read endpoint, headers, TLS, compression and timeout from
+// the standard OTEL_EXPORTER_OTLP_* variables themselves.
+func newSpanExporters(ctx context.Context) ([]trace.SpanExporter, error) {
+	var spanExporters []trace.SpanExporter
+
+	for _, name := range exporters(signalTraces) {
+		var exporter trace.SpanExporter
+		var err error
+		switch name {
+		case ExporterNone:
+			continue
+		case ExporterOTLP:
+			if protocol(signalTraces) == ProtocolGRPC {
+				exporter, err = otlptracegrpc.New(ctx)
+				break
+			}
+			var opts []otlptracehttp.Option
+			if !endpointConfigured(signalTraces) {
+				// Use the OTLP HTTP endpoint of Jaeger, without TLS inside the cluster.
+				opts = append(opts, otlptracehttp.WithEndpoint(traceEndpoint), otlptracehttp.WithInsecure())
+			}
+			exporter, err = otlptracehttp.New(ctx, opts...)
+		case ExporterConsole, ExporterFile:
+			var w io.Writer
+			var closer io.Closer
+			if w, closer, err = exportWriter(signalTraces, name); err == nil {
+				exporter, err = stdouttrace.New(stdouttrace.WithWriter(w))
+				if err == nil && closer != nil {
+					exporter = closingSpanExporter{SpanExporter: exporter, closer: closer}
+				}
+			}
+		default:
+			err = fmt.Errorf("unknown exporter %q", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s trace exporter: %w", name, err)
+		}
+		spanExporters = append(spanExporters, exporter)
+	}
+	return spanExporters, nil
+}
+
+// newMetricReaders builds a periodic reader for every exporter selected by
+// OTEL_METRICS_EXPORTER. They export every 3s unless OTEL_METRIC_EXPORT_INTERVAL is set.
+func newMetricReaders(ctx context.Context) ([]metric.Reader, error) {
+	var readers []metric.Reader
+
+	var readerOpts []metric.PeriodicReaderOption
+	if config.String("OTEL_METRIC_EXPORT_INTERVAL", "") == "" {
+		// Default is 1m. Set to 3s for demonstrative purposes.
+		readerOpts = append(readerOpts, metric.WithInterval(3*time.Second))
+	}
+
+	for _, name := range exporters(signalMetrics) {
+		var exporter metric.Exporter
+		var err error
+		switch name {
+		case ExporterNone:
+			continue
+		case ExporterPrometheus:
+			reader, err := newPrometheusReader()
+			if err != nil {
+				return nil, fmt.Errorf("failed to create prometheus metric exporter: %w", err)
+			}
+			readers = append(readers, reader)
+			continue
+		case ExporterOTLP:
+			if protocol(signalMetrics) == ProtocolGRPC {
+				exporter, err = otlpmetricgrpc.New(ctx)
+			} else {
+				exporter, err = otlpmetrichttp.New(ctx)
+			}
+		case ExporterConsole, ExporterFile:
+			var w io.Writer
+			var closer io.Closer
+			if w, closer, err = exportWriter(signalMetrics, name); err == nil {
+				exporter, err = stdoutmetric.New(stdoutmetric.WithWriter(w))
+				if err == nil && closer != nil {
+					exporter = closingMetricExporter{Exporter: exporter, closer: closer}
+				}
+			}
+		default:
+			err = fmt.Errorf("unknown exporter %q", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s metric exporter: %w", name, err)
+		}
+		readers = append(readers, metric.NewPeriodicReader(exporter, readerOpts...))
+	}
+	return readers, nil
+}
+
+// newLogExporters builds the log exporters selected by OTEL_LOGS_EXPORTER.
+func newLogExporters(ctx context.Context) ([]log.Exporter, error) {
+	var logExporters []log.Exporter
+
+	for _, name := range exporters(signalLogs) {
+		var exporter log.Exporter
+		var err error
+		switch name {
+		case ExporterNone:
+			continue
+		case ExporterOTLP:
+			if protocol(signalLogs) == ProtocolGRPC {
+				exporter, err = otlploggrpc.New(ctx)
+			} else {
+				exporter, err = otlploghttp.New(ctx)
+			}
+		case ExporterConsole, ExporterFile:
+			var w io.Writer
+			var closer io.Closer
+			if w, closer, err = exportWriter(signalLogs, name); err == nil {
+				exporter, err = stdoutlog.New(stdoutlog.WithWriter(w))
+				if err == nil && closer != nil {
+					exporter = closingLogExporter{Exporter: exporter, closer: closer}
+				}
+			}
+		default:
+			err = fmt.Errorf("unknown exporter %q", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s log exporter: %w", name, err)
+		}
+		logExporters = append(logExporters, exporter)
+	}
+	return logExporters, nil
+}
+
+// traceCollectorAddress returns the host:port of the OTLP trace endpoint, or
+// false when traces are not exported over OTLP.
+func traceCollectorAddress() (string, bool) {
+	otlp := false
+	for _, name := range exporters(signalTraces) {
+		otlp = otlp || name == ExporterOTLP
+	}
+	if !otlp {
+		return "", false
+	}
+	if !endpointConfigured(signalTraces) {
+		return traceEndpoint, true
+	}
+
+	endpoint := config.String("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", config.String("OTEL_EXPORTER_OTLP_ENDPOINT", ""))
+	port := "4318"
+	if protocol(signalTraces) == ProtocolGRPC {
+		port = "4317"
+	}
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		if u.Port() != "" {
+			return u.Host, true
+		}
+		if u.Scheme == "https" {
+			port = "443"
+		}
+		return net.JoinHostPort(u.Hostname(), port), true
+	}
+	// gRPC endpoints may be given without a scheme.
+	if _, _, err := net.SplitHostPort(endpoint); err == nil {
+		return endpoint, true
+	}
+	return net.JoinHostPort(endpoint, port), true
+}
@@ -0,0 +1,33 @@
+package telemetry
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"SimpleMicroserviceProject/pkg/health"
+)
+
+// ExporterHealthCheck reports whether the OTLP trace collector accepts connections.
+// It runs in the background and does not fail the probes, since losing
+// telemetry is no reason to stop serving traffic.
+func ExporterHealthCheck() health.Check {
+	return health.Check{
+		Name:        "trace-exporter",
+		Probes:      []health.Probe{health.Readiness},
+		Interval:    30 * time.Second,
+		NonCritical: true,
+		Func: func(ctx context.Context) error {
+			addr, ok := traceCollectorAddress()
+			if !ok {
+				return nil
+			}
+			var dialer net.Dialer
+			conn, err := dialer.DialContext(ctx, "tcp", addr)
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		},
+	}
+}
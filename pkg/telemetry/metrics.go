@@ -0,0 +1,342 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"SimpleMicroserviceProject/pkg/config"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// DefaultCardinalityLimit is the number of distinct attribute sets a metric
+// records before the overflow policy applies, unless METRICS_CARDINALITY_LIMIT is set.
+const DefaultCardinalityLimit = 100
+
+// OverflowAttribute replaces the attributes of aggregated measurements beyond
+// the cardinality limit, as the OpenTelemetry SDK does for its own limit.
+var OverflowAttribute = attribute.Bool("otel.metric.overflow", true)
+
+// instrumentName is the instrument name syntax of the OpenTelemetry specification.
+var instrumentName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_./-]{0,254}$`)
+
+// OverflowPolicy decides what happens to measurements with a new attribute
+// set once a metric reached its cardinality limit.
+type OverflowPolicy int
+
+const (
+	// OverflowAggregate records them with OverflowAttribute as their only attribute.
+	OverflowAggregate OverflowPolicy = iota
+	// OverflowDrop drops them.
+	OverflowDrop
+)
+
+func (p OverflowPolicy) String() string {
+	if p == OverflowDrop {
+		return "drop"
+	}
+	return "aggregate"
+}
+
+// MetricDef declares a business metric.
+type MetricDef struct {
+	Name        string
+	Description string
+	Unit        string
+	// Buckets are the explicit bucket boundaries of a histogram, in increasing
+	// order. The SDK defaults apply when they are empty.
+	Buckets []float64
+	// AttributeKeys are the only attributes recorded, others are removed.
+	AttributeKeys []string
+	// MaxCardinality limits the distinct attribute sets, see DefaultCardinalityLimit.
+	MaxCardinality int
+	Overflow       OverflowPolicy
+}
+
+// validate checks def for an instrument that takes buckets if histogram is set.
+func (def MetricDef) validate(histogram bool) error {
+	var problems []string
+	if !instrumentName.MatchString(def.Name) {
+		problems = append(problems, "invalid name")
+	}
+	if def.Description == "" {
+		problems = append(problems, "missing description")
+	}
+	if len(def.Unit) > 63 {
+		problems = append(problems, "unit longer than 63 characters")
+	}
+	if len(def.Buckets) > 0 && !histogram {
+		problems = append(problems, "buckets are only allowed on histograms")
+	}
+	for i := 1; i < len(def.Buckets); i++ {
+		if def.Buckets[i] <= def.Buckets[i-1] {
+			problems = append(problems, "buckets are not in increasing order")
+			break
+		}
+	}
+	for i, key := range def.AttributeKeys {
+		if key == "" {
+			problems = append(problems, "empty attribute key")
+		} else if slices.Contains(def.AttributeKeys[:i], key) {
+			problems = append(problems, fmt.Sprintf("duplicate attribute key %q", key))
+		}
+	}
+	if def.MaxCardinality < 0 {
+		problems = append(problems, "negative cardinality limit")
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("metric %q: %s", def.Name, strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// Registry creates the business metrics a service declares. Declarations
+// never fail; invalid metrics record nothing and are reported by Validate,
+// which services call at startup.
+type Registry struct {
+	meter    metric.Meter
+	limit    int
+	overflow metric.Int64Counter
+
+	mu    sync.Mutex
+	names map[string]struct{}
+	errs  []error
+}
+
+// NewRegistry returns a registry creating instruments with meter.
+func NewRegistry(meter metric.Meter) *Registry {
+	r := &Registry{
+		meter: meter,
+		limit: config.Int("METRICS_CARDINALITY_LIMIT", DefaultCardinalityLimit),
+		names: make(map[string]struct{}),
+	}
+	var err error
+	r.overflow, err = meter.Int64Counter("telemetry.metric.overflow",
+		metric.WithDescription("The number of measurements beyond the cardinality limit of a metric"),
+		metric.WithUnit("{measurement}"))
+	if err != nil {
+		telemetryLogger.WithError(err).Warn("Failed to create metric overflow counter")
+	}
+	return r
+}
+
+// Validate returns the errors of all declarations so far.
+func (r *Registry) Validate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return errors.Join(r.errs...)
+}
+
+// declare validates def and reserves its name. It returns nil if def is invalid.
+func (r *Registry) declare(def MetricDef, histogram bool, create func() error) *guard {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err := def.validate(histogram)
+	if _, ok := r.names[def.Name]; ok {
+		err = fmt.Errorf("metric %q: declared twice", def.Name)
+	}
+	if err == nil {
+		if err = create(); err != nil {
+			err = fmt.Errorf("metric %q: %w", def.Name, err)
+		}
+	}
+	if err != nil {
+		r.errs = append(r.errs, err)
+		return nil
+	}
+	r.names[def.Name] = struct{}{}
+
+	limit := def.MaxCardinality
+	if limit == 0 {
+		limit = r.limit
+	}
+	return newGuard(def, limit, r.overflow)
+}
+
+func instrumentOptions(def MetricDef) (metric.InstrumentOption, metric.InstrumentOption) {
+	return metric.WithDescription(def.Description), metric.WithUnit(def.Unit)
+}
+
+// Counter declares a monotonic counter.
+func (r *Registry) Counter(def MetricDef) *Counter {
+	c := &Counter{}
+	c.guard = r.declare(def, false, func() (err error) {
+		description, unit := instrumentOptions(def)
+		c.counter, err = r.meter.Int64Counter(def.Name, description, unit)
+		return err
+	})
+	return c
+}
+
+// UpDownCounter declares a counter that can go down, e.g. items in stock.
+func (r *Registry) UpDownCounter(def MetricDef) *UpDownCounter {
+	c := &UpDownCounter{}
+	c.guard = r.declare(def, false, func() (err error) {
+		description, unit := instrumentOptions(def)
+		c.counter, err = r.meter.Int64UpDownCounter(def.Name, description, unit)
+		return err
+	})
+	return c
+}
+
+// Histogram declares a histogram with def.Buckets as boundaries.
+func (r *Registry) Histogram(def MetricDef) *Histogram {
+	h := &Histogram{}
+	h.guard = r.declare(def, true, func() (err error) {
+		description, unit := instrumentOptions(def)
+		opts := []metric.Float64HistogramOption{description, unit}
+		if len(def.Buckets) > 0 {
+			opts = append(opts, metric.WithExplicitBucketBoundaries(def.Buckets...))
+		}
+		h.histogram, err = r.meter.Float64Histogram(def.Name, opts...)
+		return err
+	})
+	return h
+}
+
+// ObserveFunc reports the current value of a gauge for attrs.
+type ObserveFunc func(value float64, attrs ...attribute.KeyValue)
+
+// Gauge declares a gauge whose values observe reports on every collection.
+func (r *Registry) Gauge(def MetricDef, observe func(ctx context.Context, report ObserveFunc)) {
+	var g *guard
+	// The callback only runs on collection, after g is set. g stays nil if
+	// creating the gauge failed, which may happen after the SDK registered it.
+	g = r.declare(def, false, func() error {
+		description, unit := instrumentOptions(def)
+		_, err := r.meter.Float64ObservableGauge(def.Name, description, unit,
+			metric.WithFloat64Callback(func(ctx context.Context, o metric.Float64Observer) error {
+				if g == nil {
+					return nil
+				}
+				observe(ctx, func(value float64, attrs ...attribute.KeyValue) {
+					if opt, ok := g.attributes(ctx, attrs); ok {
+						o.Observe(value, opt)
+					}
+				})
+				return nil
+			}))
+		return err
+	})
+}
+
+// Counter is a declared monotonic counter.
+type Counter struct {
+	counter metric.Int64Counter
+	guard   *guard
+}
+
+// Add increments the counter by incr.
+func (c *Counter) Add(ctx context.Context, incr int64, attrs ...attribute.KeyValue) {
+	if c.guard == nil {
+		return
+	}
+	if opt, ok := c.guard.attributes(ctx, attrs); ok {
+		c.counter.Add(ctx, incr, opt)
+	}
+}
+
+// UpDownCounter is a declared counter that can go down.
+type UpDownCounter struct {
+	counter metric.Int64UpDownCounter
+	guard   *guard
+}
+
+// Add changes the counter by incr.
+func (c *UpDownCounter) Add(ctx context.Context, incr int64, attrs ...attribute.KeyValue) {
+	if c.guard == nil {
+		return
+	}
+	if opt, ok := c.guard.attributes(ctx, attrs); ok {
+		c.counter.Add(WithoutExemplar(ctx), incr, opt)
+	}
+}
+
+// Histogram is a declared histogram.
+type Histogram struct {
+	histogram metric.Float64Histogram
+	guard     *guard
+}
+
+// Record adds value to the distribution.
+func (h *Histogram) Record(ctx context.Context, value float64, attrs ...attribute.KeyValue) {
+	if h.guard == nil {
+		return
+	}
+	if opt, ok := h.guard.attributes(ctx, attrs); ok {
+		h.histogram.Record(ctx, value, opt)
+	}
+}
+
+// guard keeps the declared attributes of a metric and limits how many
+// distinct attribute sets it records.
+type guard struct {
+	name     string
+	allowed  []string
+	limit    int
+	policy   OverflowPolicy
+	overflow metric.Int64Counter
+	warnOnce sync.Once
+
+	mu   sync.RWMutex
+	seen map[attribute.Distinct]struct{}
+}
+
+func newGuard(def MetricDef, limit int, overflow metric.Int64Counter) *guard {
+	return &guard{
+		name:     def.Name,
+		allowed:  def.AttributeKeys,
+		limit:    limit,
+		policy:   def.Overflow,
+		overflow: overflow,
+		seen:     make(map[attribute.Distinct]struct{}),
+	}
+}
+
+var overflowSet = attribute.NewSet(OverflowAttribute)
+
+// attributes returns the attributes to record attrs with, or false if the
+// measurement is dropped.
+func (g *guard) attributes(ctx context.Context, attrs []attribute.KeyValue) (metric.MeasurementOption, bool) {
+	set, _ := attribute.NewSetWithFiltered(attrs, func(kv attribute.KeyValue) bool {
+		return slices.Contains(g.allowed, string(kv.Key))
+	})
+	key := set.Equivalent()
+
+	g.mu.RLock()
+	_, ok := g.seen[key]
+	g.mu.RUnlock()
+	if ok {
+		return metric.WithAttributeSet(set), true
+	}
+
+	g.mu.Lock()
+	if _, ok = g.seen[key]; !ok && len(g.seen) < g.limit {
+		g.seen[key] = struct{}{}
+		ok = true
+	}
+	g.mu.Unlock()
+	if ok {
+		return metric.WithAttributeSet(set), true
+	}
+
+	g.warnOnce.Do(func() {
+		telemetryLogger.WithField("metric", g.name).WithField("limit", g.limit).
+			WithField("policy", g.policy.String()).Warn("Metric reached its cardinality limit")
+	})
+	if g.overflow != nil {
+		g.overflow.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("metric", g.name), attribute.String("policy", g.policy.String())))
+	}
+	if g.policy == OverflowDrop {
+		return nil, false
+	}
+	return metric.WithAttributeSet(overflowSet), true
+}
@@ -3,16 +3,14 @@ package telemetry
 import (
 	"context"
 	"errors"
-	"fmt"
 	"log/slog"
 	"time"
 
-	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"SimpleMicroserviceProject/pkg/config"
+	applog "SimpleMicroserviceProject/pkg/log"
+	"SimpleMicroserviceProject/pkg/redact"
+
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
-	"go.opentelemetry.io/otel/exporters/stdout/stdoutlog"
-	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
 	"go.opentelemetry.io/otel/log/global"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/log"
@@ -21,38 +19,40 @@ import (
 	"go.opentelemetry.io/otel/sdk/trace"
 
 	metric2 "go.opentelemetry.io/otel/metric"
-	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 	trace2 "go.opentelemetry.io/otel/trace"
 )
 
+var telemetryLogger = applog.Component(applog.ComponentTelemetry)
+
+// traceEndpoint is the OTLP HTTP endpoint of the Jaeger collector.
+const traceEndpoint = "jaeger-collector.default.svc.cluster.local:4318"
+
 type Instrumentation struct {
-	Logger  *slog.Logger
-	Tracer  trace2.Tracer
-	Meter   metric2.Meter
-	Counter metric2.Int64Counter
+	Logger *slog.Logger
+	Tracer trace2.Tracer
+	Meter  metric2.Meter
+	// Metrics declares the business metrics of the service.
+	Metrics *Registry
 }
 
 func GetNewInstrumentation(serviceName string) *Instrumentation {
-	instrument := &Instrumentation{
-		Logger: otelslog.NewLogger(serviceName),
-		Tracer: otel.Tracer(serviceName),
-		Meter:  otel.Meter(serviceName),
+	meter := otel.Meter(serviceName)
+	return &Instrumentation{
+		Logger:  applog.Logger(),
+		Tracer:  otel.Tracer(serviceName),
+		Meter:   meter,
+		Metrics: NewRegistry(meter),
 	}
-
-	var err error
-	instrument.Counter, err = instrument.Meter.Int64Counter(fmt.Sprintf("%s.incr", serviceName),
-		metric2.WithDescription(fmt.Sprintf("The number of %v added", serviceName)),
-		metric2.WithUnit("{incr}"))
-	if err != nil {
-		panic(err)
-	}
-
-	return instrument
 }
 
 // SetupOTelSDK bootstraps the OpenTelemetry pipeline.
 // If it does not return an error, make sure to call shutdown for proper cleanup.
-func SetupOTelSDK(ctx context.Context) (shutdown func(context.Context) error, tp *trace.TracerProvider, err error) {
+func SetupOTelSDK(ctx context.Context, opts ...Option) (shutdown func(context.Context) error, tp *trace.TracerProvider, err error) {
+	cfg := defaultSetupConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
 	var shutdownFunctions []func(context.Context) error
 
 	// Shutdown calls cleanup functions registered via shutdownFunc.
@@ -74,12 +74,24 @@ func SetupOTelSDK(ctx context.Context) (shutdown func(context.Context) error, tp
 		err = errors.Join(inErr, shutdown(ctx))
 	}
 
+	// Report SDK errors (e.g. failed exports) through the telemetry component logger.
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		telemetryLogger.WithError(err).Warn("OpenTelemetry error")
+	}))
+
 	// Set up propagator.
 	prop := newPropagator()
 	otel.SetTextMapPropagator(prop)
 
+	// One resource describes the service to all providers.
+	res, err := newResource(ctx, cfg)
+	if err != nil {
+		handleErr(err)
+		return
+	}
+
 	// Set up trace provider.
-	tracerProvider, err := NewTraceProvider(5 * time.Second)
+	tracerProvider, err := NewTraceProvider(res, 5*time.Second)
 	if err != nil {
 		handleErr(err)
 		return
@@ -89,16 +101,17 @@ func SetupOTelSDK(ctx context.Context) (shutdown func(context.Context) error, tp
 	otel.SetTracerProvider(tracerProvider)
 
 	// Set up meter provider.
-	meterProvider, err := newMeterProvider()
+	meterProvider, err := newMeterProvider(res)
 	if err != nil {
 		handleErr(err)
 		return
 	}
 	shutdownFunctions = append(shutdownFunctions, meterProvider.Shutdown)
 	otel.SetMeterProvider(meterProvider)
+	startRuntimeMetrics(cfg, meterProvider)
 
 	// Set up log provider.
-	loggerProvider, err := newLoggerProvider()
+	loggerProvider, err := newLoggerProvider(res)
 	if err != nil {
 		handleErr(err)
 		return
@@ -106,6 +119,11 @@ func SetupOTelSDK(ctx context.Context) (shutdown func(context.Context) error, tp
 	shutdownFunctions = append(shutdownFunctions, loggerProvider.Shutdown)
 	global.SetLoggerProvider(loggerProvider)
 
+	// Stop profiling first, so its last log records still reach the log provider.
+	if stop := startProfiling(cfg); stop != nil {
+		shutdownFunctions = append([]func(context.Context) error{stop}, shutdownFunctions...)
+	}
+
 	return
 }
 
@@ -116,52 +134,61 @@ func newPropagator() propagation.TextMapPropagator {
 	)
 }
 
-func NewTraceProvider(batchTimeout time.Duration) (*trace.TracerProvider, error) {
-	// Create an OTLP exporter to send traces to the Jaeger backend via OTLP
-	client := otlptracehttp.NewClient(
-		otlptracehttp.WithEndpoint("jaeger-collector.default.svc.cluster.local:4318"), // Use the OTLP HTTP endpoint for Jaeger
-		otlptracehttp.WithInsecure(), // Disable TLS for local testing
-	)
-
-	traceExporter, err := otlptrace.New(context.Background(), client)
+// NewTraceProvider exports spans to the exporters selected by OTEL_TRACES_EXPORTER,
+// batching them for batchTimeout unless OTEL_BSP_SCHEDULE_DELAY is set.
+// Spans are sampled as configured by SamplerConfigFromEnv.
+func NewTraceProvider(res *resource.Resource, batchTimeout time.Duration) (*trace.TracerProvider, error) {
+	samplerConfig, err := SamplerConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	sampler, err := NewSampler(samplerConfig)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create OTLP exporter: %v", err)
+		return nil, err
+	}
+	spanExporters, err := newSpanExporters(context.Background())
+	if err != nil {
+		return nil, err
 	}
 
-	traceProvider := trace.NewTracerProvider(
-		trace.WithBatcher(traceExporter, trace.WithBatchTimeout(batchTimeout)),
-		trace.WithResource(resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceNameKey.String("simple-microservice-service"),
-			semconv.ServiceVersionKey.String("1.0.0"),
-		)),
-	)
+	var batchOpts []trace.BatchSpanProcessorOption
+	if config.String("OTEL_BSP_SCHEDULE_DELAY", "") == "" {
+		batchOpts = append(batchOpts, trace.WithBatchTimeout(batchTimeout))
+	}
+	providerOpts := []trace.TracerProviderOption{trace.WithResource(res), trace.WithSampler(sampler)}
+	for _, exporter := range spanExporters {
+		batcher := trace.NewBatchSpanProcessor(NewRedactingExporter(exporter, redact.Default), batchOpts...)
+		providerOpts = append(providerOpts, trace.WithSpanProcessor(keepErrors(samplerConfig, batcher)))
+	}
+	telemetryLogger.WithField("sampler", sampler.Description()).Info("Trace sampling configured")
+
+	traceProvider := trace.NewTracerProvider(providerOpts...)
 	otel.SetTracerProvider(traceProvider)
 	return traceProvider, nil
 }
 
-func newMeterProvider() (*metric.MeterProvider, error) {
-	metricExporter, err := stdoutmetric.New()
+func newMeterProvider(res *resource.Resource) (*metric.MeterProvider, error) {
+	readers, err := newMetricReaders(context.Background())
 	if err != nil {
 		return nil, err
 	}
 
-	meterProvider := metric.NewMeterProvider(
-		metric.WithReader(metric.NewPeriodicReader(metricExporter,
-			// Default is 1m. Set to 3s for demonstrative purposes.
-			metric.WithInterval(3*time.Second))),
-	)
-	return meterProvider, nil
+	opts := []metric.Option{metric.WithResource(res)}
+	for _, reader := range readers {
+		opts = append(opts, metric.WithReader(reader))
+	}
+	return metric.NewMeterProvider(opts...), nil
 }
 
-func newLoggerProvider() (*log.LoggerProvider, error) {
-	logExporter, err := stdoutlog.New()
+func newLoggerProvider(res *resource.Resource) (*log.LoggerProvider, error) {
+	logExporters, err := newLogExporters(context.Background())
 	if err != nil {
 		return nil, err
 	}
 
-	loggerProvider := log.NewLoggerProvider(
-		log.WithProcessor(log.NewBatchProcessor(logExporter)),
-	)
-	return loggerProvider, nil
+	opts := []log.LoggerProviderOption{log.WithResource(res)}
+	for _, exporter := range logExporters {
+		opts = append(opts, log.WithProcessor(log.NewBatchProcessor(exporter)))
+	}
+	return log.NewLoggerProvider(opts...), nil
 }
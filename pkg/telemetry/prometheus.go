@@ -0,0 +1,42 @@
+package telemetry
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/sdk/metric"
+)
+
+// metricsHandler serves the Prometheus registry once the exporter is set up.
+var metricsHandler atomic.Pointer[http.Handler]
+
+// newPrometheusReader returns a reader that collects on every scrape. The
+// exporter converts names and units to Prometheus conventions, e.g. the
+// "orders.received" counter becomes orders_received_total and
+// http.server.request.duration in seconds becomes
+// http_server_request_duration_seconds.
+func newPrometheusReader() (metric.Reader, error) {
+	registry := prometheus.NewRegistry()
+	exporter, err := otelprometheus.New(otelprometheus.WithRegisterer(registry))
+	if err != nil {
+		return nil, err
+	}
+
+	// Exemplars are only part of the OpenMetrics format, which scrapers
+	// negotiate through the Accept header.
+	var handler http.Handler = promhttp.HandlerFor(registry, promhttp.HandlerOpts{EnableOpenMetrics: true})
+	metricsHandler.Store(&handler)
+	return exporter, nil
+}
+
+// MetricsHandler returns the Prometheus scrape handler, or nil unless
+// OTEL_METRICS_EXPORTER includes prometheus.
+func MetricsHandler() http.Handler {
+	if handler := metricsHandler.Load(); handler != nil {
+		return *handler
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package telemetry
+
+import (
+	"context"
+
+	"SimpleMicroserviceProject/pkg/redact"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// redactingExporter masks sensitive span, event and link attributes before the
+// spans leave the process.
+type redactingExporter struct {
+	sdktrace.SpanExporter
+	redactor *redact.Redactor
+}
+
+// NewRedactingExporter wraps exporter so spans are redacted with redactor.
+func NewRedactingExporter(exporter sdktrace.SpanExporter, redactor *redact.Redactor) sdktrace.SpanExporter {
+	return &redactingExporter{SpanExporter: exporter, redactor: redactor}
+}
+
+func (e *redactingExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	redacted := make([]sdktrace.ReadOnlySpan, len(spans))
+	for i, span := range spans {
+		redacted[i] = e.redactSpan(span)
+	}
+	return e.SpanExporter.ExportSpans(ctx, redacted)
+}
+
+func (e *redactingExporter) redactSpan(span sdktrace.ReadOnlySpan) sdktrace.ReadOnlySpan {
+	// Copy events and links, the span is shared with other processors.
+	events := append([]sdktrace.Event(nil), span.Events()...)
+	for i := range events {
+		events[i].Attributes = e.redactAttributes(events[i].Attributes)
+	}
+	links := append([]sdktrace.Link(nil), span.Links()...)
+	for i := range links {
+		links[i].Attributes = e.redactAttributes(links[i].Attributes)
+	}
+	status := span.Status()
+	status.Description = e.redactor.Text(status.Description)
+
+	return &redactedSpan{
+		ReadOnlySpan: span,
+		attributes:   e.redactAttributes(span.Attributes()),
+		events:       events,
+		links:        links,
+		status:       status,
+	}
+}
+
+func (e *redactingExporter) redactAttributes(attrs []attribute.KeyValue) []attribute.KeyValue {
+	redacted := make([]attribute.KeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		switch attr.Value.Type() {
+		case attribute.STRING:
+			if value, keep := e.redactor.String(string(attr.Key), attr.Value.AsString()); keep {
+				redacted = append(redacted, attr.Key.String(value))
+			}
+		case attribute.STRINGSLICE:
+			values := attr.Value.AsStringSlice()
+			keep := true
+			for i := range values {
+				values[i], keep = e.redactor.String(string(attr.Key), values[i])
+			}
+			if keep {
+				redacted = append(redacted, attr.Key.StringSlice(values))
+			}
+		default:
+			if mode, ok := e.redactor.KeyMode(string(attr.Key)); ok {
+				if mode != redact.ModeDrop {
+					value, _ := e.redactor.String(string(attr.Key), attr.Value.Emit())
+					redacted = append(redacted, attr.Key.String(value))
+				}
+				continue
+			}
+			redacted = append(redacted, attr)
+		}
+	}
+	return redacted
+}
+
+// redactedSpan overrides the parts of a span that can carry sensitive values.
+type redactedSpan struct {
+	sdktrace.ReadOnlySpan
+	attributes []attribute.KeyValue
+	events     []sdktrace.Event
+	links      []sdktrace.Link
+	status     sdktrace.Status
+}
+
+func (s *redactedSpan) Attributes() []attribute.KeyValue { return s.attributes }
+func (s *redactedSpan) Events() []sdktrace.Event         { return s.events }
+func (s *redactedSpan) Links() []sdktrace.Link           { return s.links }
+func (s *redactedSpan) Status() sdktrace.Status          { return s.status }
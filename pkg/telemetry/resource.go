@@ -0,0 +1,100 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+
+	"SimpleMicroserviceProject/pkg/buildinfo"
+	"SimpleMicroserviceProject/pkg/config"
+	"SimpleMicroserviceProject/pkg/profiling"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Option configures SetupOTelSDK.
+type Option func(*setupConfig)
+
+type setupConfig struct {
+	serviceName    string
+	serviceVersion string
+	runtimeMetrics bool
+	hostMetrics    bool
+	profiling      profiling.Config
+}
+
+// WithServiceName sets service.name. OTEL_SERVICE_NAME takes precedence.
+func WithServiceName(name string) Option {
+	return func(c *setupConfig) {
+		c.serviceName = name
+	}
+}
+
+// WithServiceVersion overrides service.version, which defaults to the build version.
+func WithServiceVersion(version string) Option {
+	return func(c *setupConfig) {
+		c.serviceVersion = version
+	}
+}
+
+// newResource describes the service for all three providers. Attributes from
+// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES are applied last, so they
+// override the service name and version and the detected attributes.
+func newResource(ctx context.Context, cfg *setupConfig) (*resource.Resource, error) {
+	res, err := resource.New(ctx,
+		resource.WithSchemaURL(semconv.SchemaURL),
+		resource.WithAttributes(
+			semconv.ServiceName(cfg.serviceName),
+			semconv.ServiceVersion(cfg.serviceVersion),
+		),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+		resource.WithOS(),
+		resource.WithProcessPID(),
+		resource.WithProcessExecutableName(),
+		resource.WithProcessRuntimeName(),
+		resource.WithProcessRuntimeVersion(),
+		resource.WithContainer(),
+		resource.WithDetectors(k8sDetector{}),
+		resource.WithFromEnv(),
+	)
+	// Partial results are still usable, e.g. outside a container.
+	if errors.Is(err, resource.ErrPartialResource) {
+		telemetryLogger.WithError(err).Debug("Some resource attributes could not be detected")
+		err = nil
+	}
+	return res, err
+}
+
+func defaultSetupConfig() *setupConfig {
+	return &setupConfig{
+		serviceName:    "unknown_service",
+		serviceVersion: buildinfo.Get().Version,
+		runtimeMetrics: config.Bool("OTEL_RUNTIME_METRICS", true),
+		hostMetrics:    config.Bool("OTEL_HOST_METRICS", false),
+		profiling:      profiling.ConfigFromEnv(),
+	}
+}
+
+// k8sDetector reads the pod attributes exposed through the downward API as
+// K8S_POD_NAME, K8S_POD_UID, K8S_NAMESPACE_NAME and K8S_NODE_NAME.
+type k8sDetector struct{}
+
+func (k8sDetector) Detect(context.Context) (*resource.Resource, error) {
+	var attrs []attribute.KeyValue
+	for key, env := range map[attribute.Key]string{
+		semconv.K8SPodNameKey:       "K8S_POD_NAME",
+		semconv.K8SPodUIDKey:        "K8S_POD_UID",
+		semconv.K8SNamespaceNameKey: "K8S_NAMESPACE_NAME",
+		semconv.K8SNodeNameKey:      "K8S_NODE_NAME",
+	} {
+		if value := config.String(env, ""); value != "" {
+			attrs = append(attrs, key.String(value))
+		}
+	}
+	if len(attrs) == 0 {
+		return resource.Empty(), nil
+	}
+	return resource.NewWithAttributes(semconv.SchemaURL, attrs...), nil
+}
@@ -0,0 +1,63 @@
+package telemetry
+
+import (
+	"context"
+	"time"
+
+	"SimpleMicroserviceProject/pkg/profiling"
+
+	"go.opentelemetry.io/contrib/instrumentation/host"
+	"go.opentelemetry.io/contrib/instrumentation/runtime"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// WithRuntimeMetrics enables the Go runtime metrics: heap, GC pauses,
+// goroutines and so on. OTEL_RUNTIME_METRICS sets the default (true).
+func WithRuntimeMetrics(enabled bool) Option {
+	return func(c *setupConfig) {
+		c.runtimeMetrics = enabled
+	}
+}
+
+// WithHostMetrics enables the host and process metrics: CPU, memory and
+// network usage. OTEL_HOST_METRICS sets the default (false). In a container,
+// the host metrics describe the node rather than the pod.
+func WithHostMetrics(enabled bool) Option {
+	return func(c *setupConfig) {
+		c.hostMetrics = enabled
+	}
+}
+
+// WithProfiling replaces the continuous profiling configuration read by
+// profiling.ConfigFromEnv.
+func WithProfiling(cfg profiling.Config) Option {
+	return func(c *setupConfig) {
+		c.profiling = cfg
+	}
+}
+
+// startRuntimeMetrics starts the enabled runtime and host instrumentation.
+// Failing to start them does not fail the setup.
+func startRuntimeMetrics(cfg *setupConfig, provider metric.MeterProvider) {
+	if cfg.runtimeMetrics {
+		err := runtime.Start(runtime.WithMeterProvider(provider),
+			runtime.WithMinimumReadMemStatsInterval(time.Second))
+		if err != nil {
+			telemetryLogger.WithError(err).Warn("Failed to start runtime metrics")
+		}
+	}
+	if cfg.hostMetrics {
+		if err := host.Start(host.WithMeterProvider(provider)); err != nil {
+			telemetryLogger.WithError(err).Warn("Failed to start host metrics")
+		}
+	}
+}
+
+// startProfiling starts the continuous profiler if enabled and returns its
+// stop function, or nil.
+func startProfiling(cfg *setupConfig) func(context.Context) error {
+	if !cfg.profiling.Enabled {
+		return nil
+	}
+	return profiling.Start(cfg.profiling, cfg.serviceName).Stop
+}
@@ -0,0 +1,208 @@
+package telemetry
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"SimpleMicroserviceProject/pkg/config"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// SamplingRule overrides the sampler for spans with a matching attribute.
+type SamplingRule struct {
+	// Key is the attribute to match. Rules on http.route fall back to the
+	// request path, since the route is only known after the span started.
+	Key string
+	// Value is compared exactly, or as a prefix when it ends with "*".
+	Value   string
+	Sampler sdktrace.Sampler
+}
+
+func (r SamplingRule) matches(attrs []attribute.KeyValue) bool {
+	keys := []attribute.Key{attribute.Key(r.Key)}
+	if r.Key == "http.route" {
+		keys = append(keys, "url.path", "http.target")
+	}
+	for _, key := range keys {
+		for _, attr := range attrs {
+			if attr.Key != key {
+				continue
+			}
+			value := attr.Value.Emit()
+			if prefix, ok := strings.CutSuffix(r.Value, "*"); ok {
+				return strings.HasPrefix(value, prefix)
+			}
+			return value == r.Value
+		}
+	}
+	return false
+}
+
+// ruleSampler applies the first matching rule and the fallback otherwise.
+type ruleSampler struct {
+	rules    []SamplingRule
+	fallback sdktrace.Sampler
+}
+
+func (s ruleSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	for _, rule := range s.rules {
+		if rule.matches(p.Attributes) {
+			return rule.Sampler.ShouldSample(p)
+		}
+	}
+	return s.fallback.ShouldSample(p)
+}
+
+func (s ruleSampler) Description() string {
+	return fmt.Sprintf("RuleSampler{rules:%d,fallback:%s}", len(s.rules), s.fallback.Description())
+}
+
+// recordOnlySampler records the spans its sampler drops, without sampling
+// them, so that the error span processor can still export failed spans.
+type recordOnlySampler struct {
+	sampler sdktrace.Sampler
+}
+
+func (s recordOnlySampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	result := s.sampler.ShouldSample(p)
+	if result.Decision == sdktrace.Drop {
+		result.Decision = sdktrace.RecordOnly
+	}
+	return result
+}
+
+func (s recordOnlySampler) Description() string {
+	return "RecordOnly{" + s.sampler.Description() + "}"
+}
+
+// SamplerConfig selects the trace sampler.
+type SamplerConfig struct {
+	// Sampler is one of the OTEL_TRACES_SAMPLER values: always_on, always_off,
+	// traceidratio and their parentbased_ variants.
+	Sampler string
+	// Ratio is the sampling probability of the traceidratio samplers.
+	Ratio float64
+	// Rules override the sampler for the root spans they match.
+	Rules []SamplingRule
+	// KeepErrors exports spans that end with an error status even when their
+	// trace was not sampled.
+	KeepErrors bool
+}
+
+// SamplerConfigFromEnv reads OTEL_TRACES_SAMPLER (parentbased_always_on),
+// OTEL_TRACES_SAMPLER_ARG (1), TRACE_SAMPLING_RULES and TRACE_SAMPLING_KEEP_ERRORS (true).
+// Rules are comma separated "key=value:decision" entries, where decision is
+// always, never or a ratio, e.g. "http.route=/health:never,http.route=/order:0.5".
+func SamplerConfigFromEnv() (SamplerConfig, error) {
+	cfg := SamplerConfig{
+		Sampler:    strings.ToLower(config.String("OTEL_TRACES_SAMPLER", "parentbased_always_on")),
+		Ratio:      config.Float("OTEL_TRACES_SAMPLER_ARG", 1),
+		KeepErrors: config.Bool("TRACE_SAMPLING_KEEP_ERRORS", true),
+	}
+	for _, item := range config.List("TRACE_SAMPLING_RULES", nil) {
+		rule, err := parseSamplingRule(item)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.Rules = append(cfg.Rules, rule)
+	}
+	return cfg, nil
+}
+
+func parseSamplingRule(rule string) (SamplingRule, error) {
+	i := strings.LastIndexByte(rule, ':')
+	key, value, found := strings.Cut(rule[:max(i, 0)], "=")
+	if i < 0 || !found || key == "" {
+		return SamplingRule{}, fmt.Errorf("invalid sampling rule %q, expected key=value:decision", rule)
+	}
+
+	var sampler sdktrace.Sampler
+	switch decision := strings.ToLower(rule[i+1:]); decision {
+	case "always":
+		sampler = sdktrace.AlwaysSample()
+	case "never":
+		sampler = sdktrace.NeverSample()
+	default:
+		ratio, err := strconv.ParseFloat(decision, 64)
+		if err != nil {
+			return SamplingRule{}, fmt.Errorf("invalid decision in sampling rule %q: %w", rule, err)
+		}
+		sampler = sdktrace.TraceIDRatioBased(ratio)
+	}
+	return SamplingRule{Key: strings.TrimSpace(key), Value: strings.TrimSpace(value), Sampler: sampler}, nil
+}
+
+// NewSampler builds the sampler described by cfg. Rules only apply to root
+// spans; spans with a parent follow its decision in the parentbased samplers.
+func NewSampler(cfg SamplerConfig) (sdktrace.Sampler, error) {
+	var root sdktrace.Sampler
+	parentBased := strings.HasPrefix(cfg.Sampler, "parentbased_")
+	switch strings.TrimPrefix(cfg.Sampler, "parentbased_") {
+	case "always_on":
+		root = sdktrace.AlwaysSample()
+	case "always_off":
+		root = sdktrace.NeverSample()
+	case "traceidratio":
+		root = sdktrace.TraceIDRatioBased(cfg.Ratio)
+	default:
+		return nil, fmt.Errorf("unknown sampler %q", cfg.Sampler)
+	}
+	if len(cfg.Rules) > 0 {
+		root = ruleSampler{rules: cfg.Rules, fallback: root}
+	}
+	if !parentBased {
+		if cfg.KeepErrors {
+			return recordOnlySampler{sampler: root}, nil
+		}
+		return root, nil
+	}
+
+	if !cfg.KeepErrors {
+		return sdktrace.ParentBased(root), nil
+	}
+	// Record unsampled spans too, so errors in them can still be exported.
+	recordOnly := recordOnlySampler{sampler: sdktrace.NeverSample()}
+	return sdktrace.ParentBased(recordOnlySampler{sampler: root},
+		sdktrace.WithRemoteParentNotSampled(recordOnly),
+		sdktrace.WithLocalParentNotSampled(recordOnly),
+	), nil
+}
+
+// errorSpanProcessor passes sampled spans on, plus recorded but unsampled
+// spans that ended with an error, marked as sampled so they are exported.
+type errorSpanProcessor struct {
+	sdktrace.SpanProcessor
+}
+
+func (p errorSpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if s.SpanContext().IsSampled() {
+		p.SpanProcessor.OnEnd(s)
+		return
+	}
+	if s.Status().Code == codes.Error {
+		p.SpanProcessor.OnEnd(sampledSpan{ReadOnlySpan: s})
+	}
+}
+
+// sampledSpan reports the sampled flag on a span that was only recorded.
+type sampledSpan struct {
+	sdktrace.ReadOnlySpan
+}
+
+func (s sampledSpan) SpanContext() oteltrace.SpanContext {
+	sc := s.ReadOnlySpan.SpanContext()
+	return sc.WithTraceFlags(sc.TraceFlags().WithSampled(true))
+}
+
+// keepErrors wraps processor when errors of unsampled traces are exported.
+func keepErrors(cfg SamplerConfig, processor sdktrace.SpanProcessor) sdktrace.SpanProcessor {
+	if !cfg.KeepErrors {
+		return processor
+	}
+	return errorSpanProcessor{SpanProcessor: processor}
+}
@@ -0,0 +1,143 @@
+package telemetrytest
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+)
+
+// logRecorder keeps the records emitted during one test.
+type logRecorder struct {
+	mu      sync.Mutex
+	records []sdklog.Record
+}
+
+func (r *logRecorder) add(record sdklog.Record) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, record)
+}
+
+func (r *logRecorder) all() []sdklog.Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.records)
+}
+
+// sevOffset converts slog levels to OpenTelemetry severities, as the otelslog bridge does.
+const sevOffset = slog.Level(log.SeverityDebug) - slog.LevelDebug
+
+// LogMatcher matches log records by level, message and fields.
+type LogMatcher struct {
+	level   slog.Level
+	message string
+	fields  map[string]string
+}
+
+// Log matches records at level, e.g. slog.LevelInfo. logrus levels map to
+// the slog level of the same name.
+func Log(level slog.Level) LogMatcher {
+	return LogMatcher{level: level}
+}
+
+// WithMessage also requires the message to contain substr.
+func (m LogMatcher) WithMessage(substr string) LogMatcher {
+	m.message = substr
+	return m
+}
+
+// WithField also requires a field key with value, compared as formatted by fmt.
+func (m LogMatcher) WithField(key string, value any) LogMatcher {
+	fields := make(map[string]string, len(m.fields)+1)
+	for k, v := range m.fields {
+		fields[k] = v
+	}
+	fields[key] = fmt.Sprint(value)
+	m.fields = fields
+	return m
+}
+
+// Matches reports whether record matches.
+func (m LogMatcher) Matches(record sdklog.Record) bool {
+	if record.Severity() != log.Severity(m.level+sevOffset) ||
+		!strings.Contains(record.Body().AsString(), m.message) {
+		return false
+	}
+	found := 0
+	record.WalkAttributes(func(kv log.KeyValue) bool {
+		if want, ok := m.fields[kv.Key]; ok && formatValue(kv.Value) == want {
+			found++
+		}
+		return true
+	})
+	return found == len(m.fields)
+}
+
+func (m LogMatcher) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "log at level %s", m.level)
+	if m.message != "" {
+		fmt.Fprintf(&b, " containing %q", m.message)
+	}
+	if len(m.fields) > 0 {
+		fmt.Fprintf(&b, " with fields %v", m.fields)
+	}
+	return b.String()
+}
+
+// formatValue formats v like fmt formats the Go value it was logged from.
+func formatValue(v log.Value) string {
+	switch v.Kind() {
+	case log.KindString:
+		return v.AsString()
+	case log.KindInt64:
+		return fmt.Sprint(v.AsInt64())
+	case log.KindFloat64:
+		return fmt.Sprint(v.AsFloat64())
+	case log.KindBool:
+		return fmt.Sprint(v.AsBool())
+	}
+	return v.String()
+}
+
+// Logs returns the records emitted since Install.
+func (h *Harness) Logs() []sdklog.Record {
+	return h.logs.all()
+}
+
+// FindLogs returns the records matching m.
+func (h *Harness) FindLogs(m LogMatcher) []sdklog.Record {
+	var found []sdklog.Record
+	for _, record := range h.Logs() {
+		if m.Matches(record) {
+			found = append(found, record)
+		}
+	}
+	return found
+}
+
+// AssertLog fails the test unless a record matching m was emitted.
+func (h *Harness) AssertLog(m LogMatcher) {
+	h.t.Helper()
+	if len(h.FindLogs(m)) > 0 {
+		return
+	}
+	messages := make([]string, 0, len(h.Logs()))
+	for _, record := range h.Logs() {
+		messages = append(messages, record.Body().AsString())
+	}
+	h.t.Errorf("telemetrytest: no %s, emitted: %q", m, messages)
+}
+
+// AssertNoLog fails the test if a record matching m was emitted.
+func (h *Harness) AssertNoLog(m LogMatcher) {
+	h.t.Helper()
+	if len(h.FindLogs(m)) > 0 {
+		h.t.Errorf("telemetrytest: unexpected %s", m)
+	}
+}
@@ -0,0 +1,158 @@
+package telemetrytest
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// findMetric returns the metric named name in rm.
+func findMetric(rm metricdata.ResourceMetrics, name string) (metricdata.Metrics, bool) {
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if m.Name == name {
+				return m, true
+			}
+		}
+	}
+	return metricdata.Metrics{}, false
+}
+
+// sum adds up the counter points of the metric named name with attrs.
+func sum(rm metricdata.ResourceMetrics, name string, attrs []attribute.KeyValue) (float64, bool) {
+	m, ok := findMetric(rm, name)
+	if !ok {
+		return 0, false
+	}
+	var total float64
+	switch data := m.Data.(type) {
+	case metricdata.Sum[int64]:
+		for _, p := range data.DataPoints {
+			if hasAttributes(p.Attributes.ToSlice(), attrs) {
+				total += float64(p.Value)
+			}
+		}
+	case metricdata.Sum[float64]:
+		for _, p := range data.DataPoints {
+			if hasAttributes(p.Attributes.ToSlice(), attrs) {
+				total += p.Value
+			}
+		}
+	default:
+		return 0, false
+	}
+	return total, true
+}
+
+// count adds up the counts of the histogram points of the metric named name with attrs.
+func count(rm metricdata.ResourceMetrics, name string, attrs []attribute.KeyValue) (uint64, bool) {
+	m, ok := findMetric(rm, name)
+	if !ok {
+		return 0, false
+	}
+	var total uint64
+	switch data := m.Data.(type) {
+	case metricdata.Histogram[int64]:
+		for _, p := range data.DataPoints {
+			if hasAttributes(p.Attributes.ToSlice(), attrs) {
+				total += p.Count
+			}
+		}
+	case metricdata.Histogram[float64]:
+		for _, p := range data.DataPoints {
+			if hasAttributes(p.Attributes.ToSlice(), attrs) {
+				total += p.Count
+			}
+		}
+	default:
+		return 0, false
+	}
+	return total, true
+}
+
+// CounterDelta returns how much the counter or up/down counter named name
+// changed since Install, over the points with attrs.
+func (h *Harness) CounterDelta(name string, attrs ...attribute.KeyValue) float64 {
+	h.t.Helper()
+	now, _ := sum(h.Collect(), name, attrs)
+	before, _ := sum(h.baseline, name, attrs)
+	return now - before
+}
+
+// HistogramCount returns how many values the histogram named name recorded
+// since Install, over the points with attrs.
+func (h *Harness) HistogramCount(name string, attrs ...attribute.KeyValue) uint64 {
+	h.t.Helper()
+	now, _ := count(h.Collect(), name, attrs)
+	before, _ := count(h.baseline, name, attrs)
+	return now - before
+}
+
+// Gauge returns the last value of the gauge named name with attrs.
+func (h *Harness) Gauge(name string, attrs ...attribute.KeyValue) (float64, bool) {
+	h.t.Helper()
+	m, ok := findMetric(h.Collect(), name)
+	if !ok {
+		return 0, false
+	}
+	switch data := m.Data.(type) {
+	case metricdata.Gauge[int64]:
+		for _, p := range data.DataPoints {
+			if hasAttributes(p.Attributes.ToSlice(), attrs) {
+				return float64(p.Value), true
+			}
+		}
+	case metricdata.Gauge[float64]:
+		for _, p := range data.DataPoints {
+			if hasAttributes(p.Attributes.ToSlice(), attrs) {
+				return p.Value, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// AssertCounter fails the test unless the counter named name with attrs
+// increased by delta since Install.
+func (h *Harness) AssertCounter(name string, delta float64, attrs ...attribute.KeyValue) {
+	h.t.Helper()
+	if _, ok := findMetric(h.Collect(), name); !ok {
+		h.t.Errorf("telemetrytest: no metric %q", name)
+		return
+	}
+	if got := h.CounterDelta(name, attrs...); got != delta {
+		h.t.Errorf("telemetrytest: counter %q%s changed by %v, want %v", name, attrSuffix(attrs), got, delta)
+	}
+}
+
+// AssertHistogramCount fails the test unless the histogram named name with
+// attrs recorded n values since Install.
+func (h *Harness) AssertHistogramCount(name string, n uint64, attrs ...attribute.KeyValue) {
+	h.t.Helper()
+	if _, ok := findMetric(h.Collect(), name); !ok {
+		h.t.Errorf("telemetrytest: no metric %q", name)
+		return
+	}
+	if got := h.HistogramCount(name, attrs...); got != n {
+		h.t.Errorf("telemetrytest: histogram %q%s recorded %d values, want %d", name, attrSuffix(attrs), got, n)
+	}
+}
+
+// AssertGauge fails the test unless the gauge named name with attrs is value.
+func (h *Harness) AssertGauge(name string, value float64, attrs ...attribute.KeyValue) {
+	h.t.Helper()
+	got, ok := h.Gauge(name, attrs...)
+	if !ok {
+		h.t.Errorf("telemetrytest: no gauge %q%s", name, attrSuffix(attrs))
+		return
+	}
+	if got != value {
+		h.t.Errorf("telemetrytest: gauge %q%s is %v, want %v", name, attrSuffix(attrs), got, value)
+	}
+}
+
+func attrSuffix(attrs []attribute.KeyValue) string {
+	if len(attrs) == 0 {
+		return ""
+	}
+	return " with attributes " + formatAttributes(attrs)
+}
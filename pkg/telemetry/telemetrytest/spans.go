@@ -0,0 +1,129 @@
+package telemetrytest
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// SpanMatcher matches ended spans by name, attributes, events and status.
+type SpanMatcher struct {
+	name   string
+	attrs  []attribute.KeyValue
+	events []string
+	status *codes.Code
+}
+
+// Span matches spans named name.
+func Span(name string) SpanMatcher {
+	return SpanMatcher{name: name}
+}
+
+// WithAttributes also requires the span to have attrs.
+func (m SpanMatcher) WithAttributes(attrs ...attribute.KeyValue) SpanMatcher {
+	m.attrs = append(slices.Clip(m.attrs), attrs...)
+	return m
+}
+
+// WithEvent also requires an event named name.
+func (m SpanMatcher) WithEvent(name string) SpanMatcher {
+	m.events = append(slices.Clip(m.events), name)
+	return m
+}
+
+// WithStatus also requires the status code.
+func (m SpanMatcher) WithStatus(code codes.Code) SpanMatcher {
+	m.status = &code
+	return m
+}
+
+// Matches reports whether s matches.
+func (m SpanMatcher) Matches(s sdktrace.ReadOnlySpan) bool {
+	if s.Name() != m.name || !hasAttributes(s.Attributes(), m.attrs) {
+		return false
+	}
+	for _, name := range m.events {
+		if !slices.ContainsFunc(s.Events(), func(e sdktrace.Event) bool { return e.Name == name }) {
+			return false
+		}
+	}
+	return m.status == nil || s.Status().Code == *m.status
+}
+
+func (m SpanMatcher) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "span %q", m.name)
+	if len(m.attrs) > 0 {
+		fmt.Fprintf(&b, " with attributes %s", formatAttributes(m.attrs))
+	}
+	if len(m.events) > 0 {
+		fmt.Fprintf(&b, " with events %q", m.events)
+	}
+	if m.status != nil {
+		fmt.Fprintf(&b, " with status %s", m.status)
+	}
+	return b.String()
+}
+
+// Spans returns the spans ended since Install.
+func (h *Harness) Spans() []sdktrace.ReadOnlySpan {
+	return h.spans.Ended()
+}
+
+// FindSpans returns the ended spans matching m.
+func (h *Harness) FindSpans(m SpanMatcher) []sdktrace.ReadOnlySpan {
+	var found []sdktrace.ReadOnlySpan
+	for _, s := range h.Spans() {
+		if m.Matches(s) {
+			found = append(found, s)
+		}
+	}
+	return found
+}
+
+// AssertSpan fails the test unless a span matching m ended, and returns the first one.
+func (h *Harness) AssertSpan(m SpanMatcher) sdktrace.ReadOnlySpan {
+	h.t.Helper()
+	found := h.FindSpans(m)
+	if len(found) == 0 {
+		names := make([]string, 0, len(h.Spans()))
+		for _, s := range h.Spans() {
+			names = append(names, s.Name())
+		}
+		h.t.Errorf("telemetrytest: no %s, ended spans: %q", m, names)
+		return nil
+	}
+	return found[0]
+}
+
+// AssertNoSpan fails the test if a span matching m ended.
+func (h *Harness) AssertNoSpan(m SpanMatcher) {
+	h.t.Helper()
+	if found := h.FindSpans(m); len(found) > 0 {
+		h.t.Errorf("telemetrytest: unexpected %s", m)
+	}
+}
+
+// hasAttributes reports whether have contains every attribute of want.
+func hasAttributes(have, want []attribute.KeyValue) bool {
+	for _, w := range want {
+		if !slices.ContainsFunc(have, func(kv attribute.KeyValue) bool {
+			return kv.Key == w.Key && kv.Value == w.Value
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+func formatAttributes(attrs []attribute.KeyValue) string {
+	parts := make([]string, len(attrs))
+	for i, kv := range attrs {
+		parts[i] = string(kv.Key) + "=" + kv.Value.Emit()
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
@@ -0,0 +1,121 @@
+// Package telemetrytest records spans, metrics and logs in memory so tests can
+// assert on the telemetry of the code under test.
+//
+//	h := telemetrytest.Install(t)
+//	HandleOrder(w, r)
+//	h.AssertSpan(telemetrytest.Span("HandleOrder /order").WithEvent("Completed order"))
+//	h.AssertCounter("orders.received", 1)
+//	h.AssertLog(telemetrytest.Log(slog.LevelInfo).WithMessage("Received new order"))
+//
+// The OpenTelemetry globals only delegate to the first providers set, so the
+// harness installs its providers once per test binary and routes them to the
+// recorders of the current test. Tests using it must therefore not run in
+// parallel, and must not call telemetry.SetupOTelSDK.
+package telemetrytest
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/log/global"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TB is the part of testing.TB used by the harness, so this package does
+// not have to import testing.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...any)
+	Fatalf(format string, args ...any)
+	Cleanup(func())
+}
+
+var (
+	installOnce sync.Once
+	reader      *sdkmetric.ManualReader
+
+	// The recorders of the current test, nil between tests.
+	currentSpans atomic.Pointer[tracetest.SpanRecorder]
+	currentLogs  atomic.Pointer[logRecorder]
+)
+
+// Harness holds what one test recorded.
+type Harness struct {
+	t        TB
+	spans    *tracetest.SpanRecorder
+	logs     *logRecorder
+	baseline metricdata.ResourceMetrics
+}
+
+// Install routes the global tracer, meter and logger providers to a new
+// harness until the test ends. Every span is sampled.
+func Install(t TB) *Harness {
+	t.Helper()
+	installOnce.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(
+			sdktrace.WithSampler(sdktrace.AlwaysSample()),
+			sdktrace.WithSpanProcessor(spanForwarder{}),
+		))
+		reader = sdkmetric.NewManualReader()
+		otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+		global.SetLoggerProvider(sdklog.NewLoggerProvider(sdklog.WithProcessor(logForwarder{})))
+	})
+
+	h := &Harness{t: t, spans: tracetest.NewSpanRecorder(), logs: &logRecorder{}}
+	// Metrics are cumulative, so assertions compare against what was recorded before.
+	h.baseline = h.Collect()
+	currentSpans.Store(h.spans)
+	currentLogs.Store(h.logs)
+	t.Cleanup(func() {
+		currentSpans.CompareAndSwap(h.spans, nil)
+		currentLogs.CompareAndSwap(h.logs, nil)
+	})
+	return h
+}
+
+// Collect returns the cumulative state of all metrics.
+func (h *Harness) Collect() metricdata.ResourceMetrics {
+	h.t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		h.t.Fatalf("telemetrytest: failed to collect metrics: %v", err)
+	}
+	return rm
+}
+
+// spanForwarder passes spans to the recorder of the current test.
+type spanForwarder struct{}
+
+func (spanForwarder) OnStart(ctx context.Context, s sdktrace.ReadWriteSpan) {
+	if recorder := currentSpans.Load(); recorder != nil {
+		recorder.OnStart(ctx, s)
+	}
+}
+
+func (spanForwarder) OnEnd(s sdktrace.ReadOnlySpan) {
+	if recorder := currentSpans.Load(); recorder != nil {
+		recorder.OnEnd(s)
+	}
+}
+
+func (spanForwarder) Shutdown(context.Context) error   { return nil }
+func (spanForwarder) ForceFlush(context.Context) error { return nil }
+
+// logForwarder passes log records to the recorder of the current test.
+type logForwarder struct{}
+
+func (logForwarder) OnEmit(_ context.Context, record *sdklog.Record) error {
+	if recorder := currentLogs.Load(); recorder != nil {
+		recorder.add(record.Clone())
+	}
+	return nil
+}
+
+func (logForwarder) Shutdown(context.Context) error   { return nil }
+func (logForwarder) ForceFlush(context.Context) error { return nil }
@@ -0,0 +1,123 @@
+package telemetrytest_test
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	applog "SimpleMicroserviceProject/pkg/log"
+	"SimpleMicroserviceProject/pkg/telemetry/telemetrytest"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// recordingTB records failures instead of failing the test.
+type recordingTB struct {
+	*testing.T
+	failures []string
+}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+// expectFailure runs assert on a harness installed for a recording TB and
+// fails unless the assertion reported exactly one failure.
+func expectFailure(t *testing.T, assert func(*telemetrytest.Harness)) {
+	t.Helper()
+	tb := &recordingTB{T: t}
+	assert(telemetrytest.Install(tb))
+	if len(tb.failures) != 1 {
+		t.Errorf("got failures %q, want one", tb.failures)
+	}
+}
+
+func TestSpanMatcher(t *testing.T) {
+	h := telemetrytest.Install(t)
+
+	_, span := otel.Tracer("test").Start(context.Background(), "checkout")
+	span.SetAttributes(attribute.String("cart", "full"), attribute.Int("items", 3))
+	span.AddEvent("paid")
+	span.SetStatus(codes.Error, "declined")
+	span.End()
+
+	h.AssertSpan(telemetrytest.Span("checkout").
+		WithAttributes(attribute.Int("items", 3)).
+		WithEvent("paid").
+		WithStatus(codes.Error))
+	h.AssertNoSpan(telemetrytest.Span("checkout").WithAttributes(attribute.Int("items", 4)))
+	h.AssertNoSpan(telemetrytest.Span("checkout").WithStatus(codes.Ok))
+	h.AssertNoSpan(telemetrytest.Span("refund"))
+
+	expectFailure(t, func(h *telemetrytest.Harness) {
+		h.AssertSpan(telemetrytest.Span("checkout"))
+	})
+}
+
+func TestCounterDeltaSinceInstall(t *testing.T) {
+	counter, err := otel.Meter("test").Int64Counter("test.requests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	attrs := attribute.String("route", "/order")
+
+	h := telemetrytest.Install(t)
+	counter.Add(context.Background(), 2, metric.WithAttributes(attrs))
+	h.AssertCounter("test.requests", 2, attrs)
+
+	// A new harness starts from what was recorded so far.
+	h = telemetrytest.Install(t)
+	counter.Add(context.Background(), 3, metric.WithAttributes(attrs))
+	counter.Add(context.Background(), 1, metric.WithAttributes(attribute.String("route", "/health")))
+	h.AssertCounter("test.requests", 3, attrs)
+	h.AssertCounter("test.requests", 4)
+	if got := h.CounterDelta("test.requests", attribute.String("route", "/item")); got != 0 {
+		t.Errorf("CounterDelta of an unused route = %v, want 0", got)
+	}
+
+	expectFailure(t, func(h *telemetrytest.Harness) {
+		counter.Add(context.Background(), 1, metric.WithAttributes(attrs))
+		h.AssertCounter("test.requests", 2, attrs)
+	})
+	expectFailure(t, func(h *telemetrytest.Harness) {
+		h.AssertCounter("test.missing", 0)
+	})
+}
+
+func TestHistogramCountSinceInstall(t *testing.T) {
+	histogram, err := otel.Meter("test").Float64Histogram("test.duration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	histogram.Record(context.Background(), 0.5)
+
+	h := telemetrytest.Install(t)
+	histogram.Record(context.Background(), 1)
+	histogram.Record(context.Background(), 2)
+	h.AssertHistogramCount("test.duration", 2)
+}
+
+func TestLogMatcherLevels(t *testing.T) {
+	h := telemetrytest.Install(t)
+
+	applog.Component(applog.ComponentDB).WithField("table", "orders").Warn("Slow query")
+	applog.Logger().Error("Payment declined", "amount", 99.99, "retry", false)
+
+	// logrus and slog records map to the slog level of the same name.
+	h.AssertLog(telemetrytest.Log(slog.LevelWarn).WithMessage("Slow query").WithField("table", "orders"))
+	h.AssertLog(telemetrytest.Log(slog.LevelError).
+		WithMessage("Payment declined").
+		WithField("amount", 99.99).
+		WithField("retry", false))
+	h.AssertNoLog(telemetrytest.Log(slog.LevelInfo).WithMessage("Slow query"))
+	h.AssertNoLog(telemetrytest.Log(slog.LevelError).WithMessage("Slow query"))
+	h.AssertNoLog(telemetrytest.Log(slog.LevelWarn).WithField("table", "items"))
+
+	expectFailure(t, func(h *telemetrytest.Harness) {
+		h.AssertLog(telemetrytest.Log(slog.LevelWarn).WithMessage("Slow query"))
+	})
+}
@@ -0,0 +1,222 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"SimpleMicroserviceProject/pkg/config"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Config describes where the service's certificates live on disk.
+type Config struct {
+	CertFile string
+	KeyFile  string
+	// CAFile verifies client certificates on the server side (mTLS)
+	// and server certificates on the client side.
+	CAFile            string
+	RequireClientCert bool
+	ReloadInterval    time.Duration
+}
+
+// FromEnv reads the TLS configuration from the TLS_* environment variables.
+func FromEnv() Config {
+	return Config{
+		CertFile:          config.String("TLS_CERT_FILE", ""),
+		KeyFile:           config.String("TLS_KEY_FILE", ""),
+		CAFile:            config.String("TLS_CA_FILE", ""),
+		RequireClientCert: config.Bool("TLS_REQUIRE_CLIENT_CERT", false),
+		ReloadInterval:    config.Duration("TLS_RELOAD_INTERVAL", 30*time.Second),
+	}
+}
+
+// Enabled reports whether a certificate and key were configured.
+func (c Config) Enabled() bool {
+	return c.CertFile != "" && c.KeyFile != ""
+}
+
+// Reloader keeps the certificate and CA pool in memory and reloads them
+// when the files on disk change, so rotated certificates are picked up
+// without restarting the service.
+type Reloader struct {
+	config Config
+	cert   atomic.Pointer[tls.Certificate]
+	pool   atomic.Pointer[x509.CertPool]
+
+	mu      sync.Mutex
+	modTime map[string]time.Time
+}
+
+// NewReloader loads the configured files once and returns the reloader.
+func NewReloader(cfg Config) (*Reloader, error) {
+	if !cfg.Enabled() {
+		return nil, errors.New("tls: certificate and key files are required")
+	}
+	if cfg.RequireClientCert && cfg.CAFile == "" {
+		return nil, errors.New("tls: client certificates require a CA file")
+	}
+
+	r := &Reloader{config: cfg, modTime: map[string]time.Time{}}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Watch polls the certificate files until ctx is done and reloads them on change.
+// A failed reload is logged and the previous certificate stays in use.
+func (r *Reloader) Watch(ctx context.Context) {
+	interval := r.config.ReloadInterval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+			if err := r.reload(); err != nil {
+				log.WithError(err).Warn("Failed to reload TLS certificates")
+				continue
+			}
+			log.WithField("cert", r.config.CertFile).Info("Reloaded TLS certificates")
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *Reloader) files() []string {
+	files := []string{r.config.CertFile, r.config.KeyFile}
+	if r.config.CAFile != "" {
+		files = append(files, r.config.CAFile)
+	}
+	return files
+}
+
+// changed reports whether any of the files was modified since the last load.
+func (r *Reloader) changed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		if !info.ModTime().Equal(r.modTime[file]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reloader) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cert, err := tls.LoadX509KeyPair(r.config.CertFile, r.config.KeyFile)
+	if err != nil {
+		return fmt.Errorf("tls: failed to load key pair: %w", err)
+	}
+
+	var pool *x509.CertPool
+	if r.config.CAFile != "" {
+		pem, err := os.ReadFile(r.config.CAFile)
+		if err != nil {
+			return fmt.Errorf("tls: failed to read CA file: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("tls: no certificates found in %s", r.config.CAFile)
+		}
+	}
+
+	r.cert.Store(&cert)
+	if pool != nil {
+		r.pool.Store(pool)
+	}
+	for _, file := range r.files() {
+		if info, err := os.Stat(file); err == nil {
+			r.modTime[file] = info.ModTime()
+		}
+	}
+	return nil
+}
+
+// nextProtos are the ALPN protocols offered, preferring HTTP/2 as net/http
+// does for its own TLS configurations.
+var nextProtos = []string{"h2", "http/1.1"}
+
+// ServerConfig returns a tls.Config for the HTTP server. Every handshake uses
+// the most recently loaded certificate and client CA pool.
+func (r *Reloader) ServerConfig() *tls.Config {
+	base := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: nextProtos,
+	}
+	cfg := base.Clone()
+	cfg.GetCertificate = func(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		return r.cert.Load(), nil
+	}
+	// The returned config replaces cfg for the handshake, so it starts from
+	// the same base to keep offering HTTP/2.
+	cfg.GetConfigForClient = func(_ *tls.ClientHelloInfo) (*tls.Config, error) {
+		clientCfg := base.Clone()
+		clientCfg.Certificates = []tls.Certificate{*r.cert.Load()}
+		if pool := r.pool.Load(); pool != nil {
+			clientCfg.ClientCAs = pool
+			clientCfg.ClientAuth = tls.VerifyClientCertIfGiven
+			if r.config.RequireClientCert {
+				clientCfg.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+		}
+		return clientCfg, nil
+	}
+	return cfg
+}
+
+// ClientConfig returns a tls.Config presenting the service certificate to
+// servers and verifying them against the current CA pool.
+func (r *Reloader) ClientConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    r.pool.Load(),
+		GetClientCertificate: func(_ *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return r.cert.Load(), nil
+		},
+	}
+}
+
+// ConfigureTransport makes the transport dial TLS with a fresh ClientConfig
+// per connection, so CA rotations apply to new connections too.
+func (r *Reloader) ConfigureTransport(transport *http.Transport) {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	// A custom dialer turns HTTP/2 off unless it is forced.
+	transport.ForceAttemptHTTP2 = true
+	transport.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		cfg := r.ClientConfig()
+		cfg.ServerName = host
+		cfg.NextProtos = nextProtos
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: cfg}
+		return tlsDialer.DialContext(ctx, network, addr)
+	}
+}
@@ -0,0 +1,170 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCA signs certificates for 127.0.0.1.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// writeLeaf writes a certificate with serial, valid for servers and clients, to certFile and keyFile.
+func (ca *testCA) writeLeaf(t *testing.T, serial int64, certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "order-service"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	writeFile(t, keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+// writeFile writes data with a modification time after any earlier write,
+// since the reloader compares modification times.
+func writeFile(t *testing.T, name string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(name, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now()
+	if info, err := os.Stat(name); err == nil && !info.ModTime().Before(modTime) {
+		modTime = info.ModTime()
+	}
+	if err := os.Chtimes(name, modTime, modTime.Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloaderServesRotatedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	cfg := Config{
+		CertFile:          filepath.Join(dir, "tls.crt"),
+		KeyFile:           filepath.Join(dir, "tls.key"),
+		CAFile:            filepath.Join(dir, "ca.crt"),
+		RequireClientCert: true,
+		ReloadInterval:    10 * time.Millisecond,
+	}
+	ca := newTestCA(t)
+	writeFile(t, cfg.CAFile, ca.pem)
+	ca.writeLeaf(t, 100, cfg.CertFile, cfg.KeyFile)
+
+	reloader, err := NewReloader(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go reloader.Watch(ctx)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{
+		TLSConfig: reloader.ServerConfig(),
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(r.TLS.PeerCertificates) == 0 {
+				http.Error(w, "no client certificate", http.StatusUnauthorized)
+			}
+		}),
+	}
+	go func() { _ = server.ServeTLS(listener, "", "") }()
+	defer server.Close()
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	reloader.ConfigureTransport(transport)
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Transport: transport, Timeout: 5 * time.Second}
+	url := "https://" + listener.Addr().String()
+
+	// servedSerial makes a request on a new connection and returns the serial
+	// of the server certificate.
+	servedSerial := func() int64 {
+		t.Helper()
+		transport.CloseIdleConnections()
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+		}
+		if resp.ProtoMajor != 2 {
+			t.Errorf("protocol = %s, want HTTP/2.0", resp.Proto)
+		}
+		return resp.TLS.PeerCertificates[0].SerialNumber.Int64()
+	}
+
+	if serial := servedSerial(); serial != 100 {
+		t.Fatalf("served serial = %d, want 100", serial)
+	}
+
+	ca.writeLeaf(t, 200, cfg.CertFile, cfg.KeyFile)
+	deadline := time.Now().Add(5 * time.Second)
+	for servedSerial() != 200 {
+		if time.Now().After(deadline) {
+			t.Fatal("rotated certificate was not served")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,20 @@
+package worker
+
+import (
+	"context"
+	"time"
+)
+
+// Job is the envelope queued for a worker pool. It carries what the pool
+// needs to know about the value besides the value itself.
+type Job[T any] struct {
+	Value      T
+	EnqueuedAt time.Time
+	// Origin connects the processing span to the request, see StartSpan.
+	Origin Origin
+}
+
+// NewJob wraps value for queueing from the request context ctx.
+func NewJob[T any](ctx context.Context, value T) Job[T] {
+	return Job[T]{Value: value, EnqueuedAt: time.Now(), Origin: OriginFromContext(ctx)}
+}
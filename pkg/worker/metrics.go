@@ -0,0 +1,124 @@
+package worker
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	applog "SimpleMicroserviceProject/pkg/log"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const meterName = "SimpleMicroserviceProject/pkg/worker"
+
+var workerLogger = applog.Component(applog.ComponentWorker)
+
+// durationBuckets are the histogram boundaries of the wait and job durations
+// in seconds. The SDK defaults are meant for milliseconds.
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// Metrics instruments a worker pool: queue depth, how long jobs wait and run,
+// how many workers are busy and how many jobs were dropped.
+// Every metric carries the service and pool name.
+type Metrics struct {
+	attrs metric.MeasurementOption
+	busy  atomic.Int64
+
+	wait     metric.Float64Histogram
+	duration metric.Float64Histogram
+	dropped  metric.Int64Counter
+}
+
+// NewMetrics instruments the pool of service named pool. depth reports the
+// number of queued jobs and alive the number of running workers, e.g.
+// the Alive method of the pool's health.WorkerMonitor.
+func NewMetrics(service, pool string, depth func() int, alive func() int) *Metrics {
+	meter := otel.Meter(meterName)
+	attrs := attribute.NewSet(attribute.String("service", service), attribute.String("pool", pool))
+	m := &Metrics{attrs: metric.WithAttributeSet(attrs)}
+
+	var err error
+	m.wait, err = meter.Float64Histogram("worker.job.wait.duration",
+		metric.WithDescription("Time jobs spent queued before a worker picked them up"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(durationBuckets...))
+	if err != nil {
+		workerLogger.WithError(err).Warn("Failed to create job wait histogram")
+	}
+	m.duration, err = meter.Float64Histogram("worker.job.duration",
+		metric.WithDescription("Time workers spent processing a job"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(durationBuckets...))
+	if err != nil {
+		workerLogger.WithError(err).Warn("Failed to create job duration histogram")
+	}
+	m.dropped, err = meter.Int64Counter("worker.jobs.dropped",
+		metric.WithDescription("The number of queued jobs that were never processed"),
+		metric.WithUnit("{job}"))
+	if err != nil {
+		workerLogger.WithError(err).Warn("Failed to create dropped jobs counter")
+	}
+
+	queueDepth, err := meter.Int64ObservableGauge("worker.queue.depth",
+		metric.WithDescription("The number of jobs waiting in the queue"),
+		metric.WithUnit("{job}"))
+	if err != nil {
+		workerLogger.WithError(err).Warn("Failed to create queue depth gauge")
+	}
+	workers, err := meter.Int64ObservableGauge("worker.workers",
+		metric.WithDescription("The number of running workers by state (busy or idle)"),
+		metric.WithUnit("{worker}"))
+	if err != nil {
+		workerLogger.WithError(err).Warn("Failed to create workers gauge")
+	}
+	if queueDepth == nil || workers == nil {
+		return m
+	}
+
+	busyAttrs := metric.WithAttributeSet(attribute.NewSet(append(attrs.ToSlice(), attribute.String("state", "busy"))...))
+	idleAttrs := metric.WithAttributeSet(attribute.NewSet(append(attrs.ToSlice(), attribute.String("state", "idle"))...))
+	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		o.ObserveInt64(queueDepth, int64(depth()), m.attrs)
+		busy := m.busy.Load()
+		o.ObserveInt64(workers, busy, busyAttrs)
+		o.ObserveInt64(workers, max(int64(alive())-busy, 0), idleAttrs)
+		return nil
+	}, queueDepth, workers)
+	if err != nil {
+		workerLogger.WithError(err).Warn("Failed to register worker pool metrics")
+	}
+	return m
+}
+
+// Started records that a worker picked up a job queued at enqueuedAt. The
+// returned function must be called when the job is done, with its error.
+func (m *Metrics) Started(ctx context.Context, enqueuedAt time.Time) func(err error) {
+	start := time.Now()
+	m.busy.Add(1)
+	if m.wait != nil && !enqueuedAt.IsZero() {
+		m.wait.Record(ctx, start.Sub(enqueuedAt).Seconds(), m.attrs)
+	}
+
+	return func(err error) {
+		m.busy.Add(-1)
+		if m.duration == nil {
+			return
+		}
+		outcome := "success"
+		if err != nil {
+			outcome = "failure"
+		}
+		m.duration.Record(ctx, time.Since(start).Seconds(), m.attrs,
+			metric.WithAttributes(attribute.String("outcome", outcome)))
+	}
+}
+
+// Dropped counts a job that was never processed, e.g. reason "shutdown".
+func (m *Metrics) Dropped(ctx context.Context, reason string) {
+	if m.dropped != nil {
+		m.dropped.Add(ctx, 1, m.attrs, metric.WithAttributes(attribute.String("reason", reason)))
+	}
+}
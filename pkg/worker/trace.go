@@ -0,0 +1,67 @@
+package worker
+
+import (
+	"context"
+	"strings"
+
+	"SimpleMicroserviceProject/pkg/config"
+	"SimpleMicroserviceProject/pkg/profiling"
+
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceMode decides how the span processing a job relates to the span of
+// the request that queued it.
+type TraceMode string
+
+const (
+	// TraceModeLink starts a new trace linked to the request. The request
+	// trace stays short and the processing is sampled on its own.
+	TraceModeLink TraceMode = "link"
+	// TraceModeChild continues the request trace, so it shows the processing too.
+	TraceModeChild TraceMode = "child"
+)
+
+// TraceModeFromEnv reads WORKER_TRACE_MODE (link or child, link by default).
+func TraceModeFromEnv() TraceMode {
+	mode := TraceMode(strings.ToLower(config.String("WORKER_TRACE_MODE", string(TraceModeLink))))
+	if mode != TraceModeLink && mode != TraceModeChild {
+		workerLogger.WithField("mode", mode).Warn("Unknown worker trace mode, using link")
+		return TraceModeLink
+	}
+	return mode
+}
+
+// Origin is the trace context of the request that queued a job.
+type Origin struct {
+	SpanContext trace.SpanContext
+	Baggage     baggage.Baggage
+}
+
+// OriginFromContext captures the span context and baggage of ctx.
+func OriginFromContext(ctx context.Context) Origin {
+	return Origin{
+		SpanContext: trace.SpanContextFromContext(ctx),
+		Baggage:     baggage.FromContext(ctx),
+	}
+}
+
+// StartSpan starts the consumer span processing a job queued from origin.
+// The returned context carries the origin's baggage, and the span is a child
+// of the origin's span or a new root linked to it, depending on mode.
+func StartSpan(ctx context.Context, tracer trace.Tracer, mode TraceMode, name string, origin Origin,
+	opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	ctx = baggage.ContextWithBaggage(ctx, origin.Baggage)
+	opts = append(opts, trace.WithSpanKind(trace.SpanKindConsumer))
+	if origin.SpanContext.IsValid() {
+		if mode == TraceModeChild {
+			ctx = trace.ContextWithSpanContext(ctx, origin.SpanContext)
+		} else {
+			opts = append(opts, trace.WithNewRoot(), trace.WithLinks(trace.Link{SpanContext: origin.SpanContext}))
+		}
+	}
+	ctx, span := tracer.Start(ctx, name, opts...)
+	// Label the worker's CPU profile samples with the job's trace.
+	return profiling.SetGoroutineLabels(ctx, "job", name), span
+}
@@ -1,24 +1,37 @@
 package src
 
 import (
-	"go.opentelemetry.io/otel/trace"
 	"sync"
+
+	"SimpleMicroserviceProject/pkg/health"
+	"SimpleMicroserviceProject/pkg/worker"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
 	tracer       trace.Tracer
-	orderChannel = make(chan Item, 10) // Buffered channel for orders
-	wg           *sync.WaitGroup       // WaitGroup to synchronize goroutines
-	done         = make(chan struct{}) // Channel to signal workers to stop
+	orderChannel = make(chan worker.Job[Item], 10) // Buffered channel for orders
+	wg           *sync.WaitGroup                   // WaitGroup to synchronize goroutines
+	done         = make(chan struct{})             // Channel to signal workers to stop
 )
 
+// workerMonitor lets the health checks see whether the workers are alive and making progress
+var workerMonitor = health.NewWorkerMonitor(ServiceName, func() int { return len(GetItemChannel()) })
+
+// poolMetrics exports queue depth, wait and processing times of the workers
+var poolMetrics = worker.NewMetrics(ServiceName, "items", func() int { return len(GetItemChannel()) }, workerMonitor.Alive)
+
+// jobTraceMode connects worker spans to the requests that queued the jobs
+var jobTraceMode = worker.TraceModeFromEnv()
+
 func GetTracer() trace.Tracer {
 	return tracer
 }
 
-func GetItemChannel() chan Item {
+func GetItemChannel() chan worker.Job[Item] {
 	if orderChannel == nil {
-		orderChannel = make(chan Item, 10) // Buffered channel for orders
+		orderChannel = make(chan worker.Job[Item], 10) // Buffered channel for orders
 	}
 	return orderChannel
 }
@@ -41,7 +54,7 @@ func SetTracer(t trace.Tracer) {
 	tracer = t
 }
 
-func SetOrderChannel(oc chan Item) {
+func SetOrderChannel(oc chan worker.Job[Item]) {
 	orderChannel = oc
 }
 
@@ -1,3 +1,7 @@
 package src
 
-const ServiceName = "item"
+const (
+	ServiceName = "item"
+	// numWorkers is the number of workers processing the queue.
+	numWorkers = 3
+)
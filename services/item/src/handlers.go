@@ -5,10 +5,11 @@ import (
 	"net/http"
 	"time"
 
+	applog "SimpleMicroserviceProject/pkg/log"
 	"SimpleMicroserviceProject/pkg/telemetry"
+	"SimpleMicroserviceProject/pkg/worker"
 
 	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/trace"
 
 	log "github.com/sirupsen/logrus"
@@ -16,6 +17,8 @@ import (
 
 var itemInstrument = telemetry.GetNewInstrumentation(ServiceName)
 
+var workerLogger = applog.Component(applog.ComponentWorker)
+
 // HandleItem processes incoming item requests
 func HandleItem(w http.ResponseWriter, r *http.Request) {
 	ctx, span := itemInstrument.Tracer.Start(r.Context(), "HandleItem /item")
@@ -26,7 +29,7 @@ func HandleItem(w http.ResponseWriter, r *http.Request) {
 		attribute.String("url", r.URL.Path)),
 	)
 	item := Item{ID: time.Now().Nanosecond(), Price: 99.99}
-	GetItemChannel() <- item
+	GetItemChannel() <- worker.NewJob(ctx, item)
 
 	itemInstrument.Logger.InfoContext(ctx, "Received new item", "result", log.Fields{
 		"itemID": item.ID,
@@ -36,9 +39,9 @@ func HandleItem(w http.ResponseWriter, r *http.Request) {
 		"url":    r.URL.Path,
 	})
 
-	itemCountAttr := attribute.Int("item.count", 1)
-	span.SetAttributes(itemCountAttr)
-	itemInstrument.Counter.Add(ctx, 1, metric.WithAttributes(itemCountAttr))
+	span.SetAttributes(attribute.Int("item.count", 1))
+	itemsReceived.Add(ctx, 1)
+	itemPrice.Record(ctx, item.Price)
 	span.AddEvent("Completed item", trace.WithAttributes(
 		attribute.String("method", r.Method),
 		attribute.String("url", r.URL.Path)),
@@ -48,37 +51,46 @@ func HandleItem(w http.ResponseWriter, r *http.Request) {
 // ProcessItems processes items in the itemChannel
 func ProcessItems(ctx context.Context, workerID int) {
 	defer GetWg().Done()
+	workerMonitor.Started()
+	defer workerMonitor.Stopped()
 
 	for {
 		select {
-		case item, ok := <-GetItemChannel():
+		case job, ok := <-GetItemChannel():
 			if !ok {
 				return // Channel closed
 			}
-
-			// Start a new span for processing items
-			var span trace.Span
-			ctx, span = GetTracer().Start(ctx, "processItems")
-			defer span.End()
-
-			logCtx := log.WithContext(ctx)
-			logCtx.WithFields(log.Fields{
-				"workerID": workerID,
-				"itemID":   item.ID,
-			}).Info("Processing item")
-
-			time.Sleep(2 * time.Second) // Simulate item processing
-
-			logCtx.WithFields(log.Fields{
-				"workerID": workerID,
-				"itemID":   item.ID,
-			}).Info("Completed item")
+			processItem(ctx, workerID, job)
+			workerMonitor.Progress()
 
 		case <-GetDone():
-			log.WithFields(log.Fields{
+			workerLogger.WithFields(log.Fields{
 				"workerID": workerID,
 			}).Info("Shutting down worker")
 			return
 		}
 	}
 }
+
+// processItem handles one queued item in its own span, connected to the
+// request that queued it as configured by WORKER_TRACE_MODE.
+func processItem(ctx context.Context, workerID int, job worker.Job[Item]) {
+	item := job.Value
+	ctx, span := worker.StartSpan(ctx, GetTracer(), jobTraceMode, "processItems", job.Origin)
+	defer span.End()
+	jobDone := poolMetrics.Started(ctx, job.EnqueuedAt)
+
+	logCtx := workerLogger.WithContext(ctx)
+	logCtx.WithFields(log.Fields{
+		"workerID": workerID,
+		"itemID":   item.ID,
+	}).Info("Processing item")
+
+	time.Sleep(2 * time.Second) // Simulate item processing
+
+	logCtx.WithFields(log.Fields{
+		"workerID": workerID,
+		"itemID":   item.ID,
+	}).Info("Completed item")
+	jobDone(nil)
+}
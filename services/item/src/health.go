@@ -3,11 +3,13 @@ package src
 import (
 	"net/http"
 
+	"SimpleMicroserviceProject/pkg/health"
+
 	log "github.com/sirupsen/logrus"
 )
 
-// HandleHealthCheck provides a health check endpoint
+// HandleHealthCheck provides a health check endpoint backed by the readiness checks
 func HandleHealthCheck(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusOK)
-	log.Info("Health check requested")
+	health.Handler(health.Readiness).ServeHTTP(w, r)
+	log.Debug("Health check requested")
 }
@@ -0,0 +1,18 @@
+package src
+
+import "SimpleMicroserviceProject/pkg/telemetry"
+
+// Business metrics of the service, validated in main.
+var (
+	itemsReceived = itemInstrument.Metrics.Counter(telemetry.MetricDef{
+		Name:        "items.received",
+		Description: "The number of items received",
+		Unit:        "{item}",
+	})
+	itemPrice = itemInstrument.Metrics.Histogram(telemetry.MetricDef{
+		Name:        "items.price",
+		Description: "The price of received items",
+		Unit:        "{USD}",
+		Buckets:     []float64{10, 25, 50, 100, 250, 500, 1000},
+	})
+)
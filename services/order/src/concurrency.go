@@ -3,23 +3,35 @@ package src
 import (
 	"sync"
 
+	"SimpleMicroserviceProject/pkg/health"
+	"SimpleMicroserviceProject/pkg/worker"
+
 	"go.opentelemetry.io/otel/trace"
 )
 
 var (
 	tracer       trace.Tracer
-	orderChannel = make(chan Order, 10) // Buffered channel for orders
-	wg           *sync.WaitGroup        // WaitGroup to synchronize goroutines
-	done         = make(chan struct{})  // Channel to signal workers to stop
+	orderChannel = make(chan worker.Job[Order], 10) // Buffered channel for orders
+	wg           *sync.WaitGroup                    // WaitGroup to synchronize goroutines
+	done         = make(chan struct{})              // Channel to signal workers to stop
 )
 
+// workerMonitor lets the health checks see whether the workers are alive and making progress
+var workerMonitor = health.NewWorkerMonitor(ServiceName, func() int { return len(GetOrderChannel()) })
+
+// poolMetrics exports queue depth, wait and processing times of the workers
+var poolMetrics = worker.NewMetrics(ServiceName, "orders", func() int { return len(GetOrderChannel()) }, workerMonitor.Alive)
+
+// jobTraceMode connects worker spans to the requests that queued the jobs
+var jobTraceMode = worker.TraceModeFromEnv()
+
 func GetTracer() trace.Tracer {
 	return tracer
 }
 
-func GetOrderChannel() chan Order {
+func GetOrderChannel() chan worker.Job[Order] {
 	if orderChannel == nil {
-		orderChannel = make(chan Order, 10) // Buffered channel for orders
+		orderChannel = make(chan worker.Job[Order], 10) // Buffered channel for orders
 	}
 	return orderChannel
 }
@@ -42,7 +54,7 @@ func SetTracer(t trace.Tracer) {
 	tracer = t
 }
 
-func SetOrderChannel(oc chan Order) {
+func SetOrderChannel(oc chan worker.Job[Order]) {
 	orderChannel = oc
 }
 
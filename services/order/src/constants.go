@@ -1,3 +1,7 @@
 package src
 
-const ServiceName = "order-service"
+const (
+	ServiceName = "order-service"
+	// numWorkers is the number of workers processing the queue.
+	numWorkers = 3
+)
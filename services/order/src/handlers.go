@@ -5,10 +5,11 @@ import (
 	"net/http"
 	"time"
 
+	applog "SimpleMicroserviceProject/pkg/log"
 	"SimpleMicroserviceProject/pkg/telemetry"
+	"SimpleMicroserviceProject/pkg/worker"
 
 	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/trace"
 
 	log "github.com/sirupsen/logrus"
@@ -16,6 +17,8 @@ import (
 
 var orderInstrument = telemetry.GetNewInstrumentation(ServiceName)
 
+var workerLogger = applog.Component(applog.ComponentWorker)
+
 // HandleOrder processes incoming order requests
 func HandleOrder(w http.ResponseWriter, r *http.Request) {
 	ctx, span := orderInstrument.Tracer.Start(r.Context(), "HandleOrder /order")
@@ -26,7 +29,7 @@ func HandleOrder(w http.ResponseWriter, r *http.Request) {
 		attribute.String("url", r.URL.Path)),
 	)
 	order := Order{ID: time.Now().Nanosecond(), Amount: 99.99}
-	GetOrderChannel() <- order
+	GetOrderChannel() <- worker.NewJob(ctx, order)
 
 	orderInstrument.Logger.InfoContext(ctx, "Received new order", "result", log.Fields{
 		"orderID": order.ID,
@@ -36,9 +39,9 @@ func HandleOrder(w http.ResponseWriter, r *http.Request) {
 		"url":     r.URL.Path,
 	})
 
-	orderCountAttr := attribute.Int("order.count", 1)
-	span.SetAttributes(orderCountAttr)
-	orderInstrument.Counter.Add(ctx, 1, metric.WithAttributes(orderCountAttr))
+	span.SetAttributes(attribute.Int("order.count", 1))
+	ordersReceived.Add(ctx, 1)
+	orderAmount.Record(ctx, order.Amount)
 	span.AddEvent("Completed order", trace.WithAttributes(
 		attribute.String("method", r.Method),
 		attribute.String("url", r.URL.Path)),
@@ -48,37 +51,46 @@ func HandleOrder(w http.ResponseWriter, r *http.Request) {
 // ProcessOrders processes orders in the orderChannel
 func ProcessOrders(ctx context.Context, workerID int) {
 	defer GetWg().Done()
+	workerMonitor.Started()
+	defer workerMonitor.Stopped()
 
 	for {
 		select {
-		case order, ok := <-GetOrderChannel():
+		case job, ok := <-GetOrderChannel():
 			if !ok {
 				return // Channel closed
 			}
-
-			// Start a new span for processing orders
-			var span trace.Span
-			ctx, span = GetTracer().Start(ctx, "processOrders")
-			defer span.End()
-
-			logCtx := log.WithContext(ctx)
-			logCtx.WithFields(log.Fields{
-				"workerID": workerID,
-				"orderID":  order.ID,
-			}).Info("Processing order")
-
-			time.Sleep(2 * time.Second) // Simulate order processing
-
-			logCtx.WithFields(log.Fields{
-				"workerID": workerID,
-				"orderID":  order.ID,
-			}).Info("Completed order")
+			processOrder(ctx, workerID, job)
+			workerMonitor.Progress()
 
 		case <-GetDone():
-			log.WithFields(log.Fields{
+			workerLogger.WithFields(log.Fields{
 				"workerID": workerID,
 			}).Info("Shutting down worker")
 			return
 		}
 	}
 }
+
+// processOrder handles one queued order in its own span, connected to the
+// request that queued it as configured by WORKER_TRACE_MODE.
+func processOrder(ctx context.Context, workerID int, job worker.Job[Order]) {
+	order := job.Value
+	ctx, span := worker.StartSpan(ctx, GetTracer(), jobTraceMode, "processOrders", job.Origin)
+	defer span.End()
+	jobDone := poolMetrics.Started(ctx, job.EnqueuedAt)
+
+	logCtx := workerLogger.WithContext(ctx)
+	logCtx.WithFields(log.Fields{
+		"workerID": workerID,
+		"orderID":  order.ID,
+	}).Info("Processing order")
+
+	time.Sleep(2 * time.Second) // Simulate order processing
+
+	logCtx.WithFields(log.Fields{
+		"workerID": workerID,
+		"orderID":  order.ID,
+	}).Info("Completed order")
+	jobDone(nil)
+}
@@ -0,0 +1,25 @@
+package src
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"SimpleMicroserviceProject/pkg/telemetry/telemetrytest"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestHandleOrderTelemetry(t *testing.T) {
+	h := telemetrytest.Install(t)
+
+	HandleOrder(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/order", nil))
+
+	h.AssertSpan(telemetrytest.Span("HandleOrder /order").
+		WithAttributes(attribute.Int("order.count", 1)).
+		WithEvent("Completed order"))
+	h.AssertCounter("orders.received", 1)
+	h.AssertHistogramCount("orders.amount", 1)
+	h.AssertLog(telemetrytest.Log(slog.LevelInfo).WithMessage("Received new order"))
+}
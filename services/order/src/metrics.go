@@ -0,0 +1,18 @@
+package src
+
+import "SimpleMicroserviceProject/pkg/telemetry"
+
+// Business metrics of the service, validated in main.
+var (
+	ordersReceived = orderInstrument.Metrics.Counter(telemetry.MetricDef{
+		Name:        "orders.received",
+		Description: "The number of orders received",
+		Unit:        "{order}",
+	})
+	orderAmount = orderInstrument.Metrics.Histogram(telemetry.MetricDef{
+		Name:        "orders.amount",
+		Description: "The amount of received orders",
+		Unit:        "{USD}",
+		Buckets:     []float64{10, 25, 50, 100, 250, 500, 1000},
+	})
+)
@@ -3,23 +3,35 @@ package src
 import (
 	"sync"
 
+	"SimpleMicroserviceProject/pkg/health"
+	"SimpleMicroserviceProject/pkg/worker"
+
 	"go.opentelemetry.io/otel/trace"
 )
 
 var (
 	tracer         trace.Tracer
-	paymentChannel = make(chan Payment, 10) // Buffered channel for payments
-	wg             *sync.WaitGroup          // WaitGroup to synchronize goroutines
-	done           = make(chan struct{})    // Channel to signal workers to stop
+	paymentChannel = make(chan worker.Job[Payment], 10) // Buffered channel for payments
+	wg             *sync.WaitGroup                      // WaitGroup to synchronize goroutines
+	done           = make(chan struct{})                // Channel to signal workers to stop
 )
 
+// workerMonitor lets the health checks see whether the workers are alive and making progress
+var workerMonitor = health.NewWorkerMonitor(ServiceName, func() int { return len(GetPaymentChannel()) })
+
+// poolMetrics exports queue depth, wait and processing times of the workers
+var poolMetrics = worker.NewMetrics(ServiceName, "payments", func() int { return len(GetPaymentChannel()) }, workerMonitor.Alive)
+
+// jobTraceMode connects worker spans to the requests that queued the jobs
+var jobTraceMode = worker.TraceModeFromEnv()
+
 func GetTracer() trace.Tracer {
 	return tracer
 }
 
-func GetPaymentChannel() chan Payment {
+func GetPaymentChannel() chan worker.Job[Payment] {
 	if paymentChannel == nil {
-		paymentChannel = make(chan Payment, 10) // Buffered channel for payments
+		paymentChannel = make(chan worker.Job[Payment], 10) // Buffered channel for payments
 	}
 	return paymentChannel
 }
@@ -42,7 +54,7 @@ func SetTracer(t trace.Tracer) {
 	tracer = t
 }
 
-func SetPaymentChannel(oc chan Payment) {
+func SetPaymentChannel(oc chan worker.Job[Payment]) {
 	paymentChannel = oc
 }
 
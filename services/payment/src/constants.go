@@ -1,3 +1,7 @@
 package src
 
-const ServiceName = "payment"
+const (
+	ServiceName = "payment"
+	// numWorkers is the number of workers processing the queue.
+	numWorkers = 3
+)
@@ -5,10 +5,11 @@ import (
 	"net/http"
 	"time"
 
+	applog "SimpleMicroserviceProject/pkg/log"
 	"SimpleMicroserviceProject/pkg/telemetry"
+	"SimpleMicroserviceProject/pkg/worker"
 
 	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/trace"
 
 	log "github.com/sirupsen/logrus"
@@ -16,6 +17,8 @@ import (
 
 var paymentInstrument = telemetry.GetNewInstrumentation(ServiceName)
 
+var workerLogger = applog.Component(applog.ComponentWorker)
+
 // HandlePayment processes incoming payment requests
 func HandlePayment(w http.ResponseWriter, r *http.Request) {
 	ctx, span := paymentInstrument.Tracer.Start(r.Context(), "HandlePayment /payment")
@@ -26,7 +29,7 @@ func HandlePayment(w http.ResponseWriter, r *http.Request) {
 		attribute.String("url", r.URL.Path)),
 	)
 	payment := Payment{ID: time.Now().Nanosecond(), Amount: 99.99}
-	GetPaymentChannel() <- payment
+	GetPaymentChannel() <- worker.NewJob(ctx, payment)
 
 	paymentInstrument.Logger.InfoContext(ctx, "Received new payment", "result", log.Fields{
 		"paymentID": payment.ID,
@@ -36,9 +39,9 @@ func HandlePayment(w http.ResponseWriter, r *http.Request) {
 		"url":       r.URL.Path,
 	})
 
-	paymentCountAttr := attribute.Int("payment.count", 1)
-	span.SetAttributes(paymentCountAttr)
-	paymentInstrument.Counter.Add(ctx, 1, metric.WithAttributes(paymentCountAttr))
+	span.SetAttributes(attribute.Int("payment.count", 1))
+	paymentsReceived.Add(ctx, 1)
+	paymentAmount.Record(ctx, payment.Amount)
 	span.AddEvent("Completed payment", trace.WithAttributes(
 		attribute.String("method", r.Method),
 		attribute.String("url", r.URL.Path)),
@@ -48,37 +51,46 @@ func HandlePayment(w http.ResponseWriter, r *http.Request) {
 // ProcessPayments processes payments in the paymentChannel
 func ProcessPayments(ctx context.Context, workerID int) {
 	defer GetWg().Done()
+	workerMonitor.Started()
+	defer workerMonitor.Stopped()
 
 	for {
 		select {
-		case payment, ok := <-GetPaymentChannel():
+		case job, ok := <-GetPaymentChannel():
 			if !ok {
 				return // Channel closed
 			}
-
-			// Start a new span for processing payments
-			var span trace.Span
-			ctx, span = GetTracer().Start(ctx, "processPayments")
-			defer span.End()
-
-			logCtx := log.WithContext(ctx)
-			logCtx.WithFields(log.Fields{
-				"workerID":  workerID,
-				"paymentID": payment.ID,
-			}).Info("Processing payment")
-
-			time.Sleep(2 * time.Second) // Simulate payment processing
-
-			logCtx.WithFields(log.Fields{
-				"workerID":  workerID,
-				"paymentID": payment.ID,
-			}).Info("Completed payment")
+			processPayment(ctx, workerID, job)
+			workerMonitor.Progress()
 
 		case <-GetDone():
-			log.WithFields(log.Fields{
+			workerLogger.WithFields(log.Fields{
 				"workerID": workerID,
 			}).Info("Shutting down worker")
 			return
 		}
 	}
 }
+
+// processPayment handles one queued payment in its own span, connected to the
+// request that queued it as configured by WORKER_TRACE_MODE.
+func processPayment(ctx context.Context, workerID int, job worker.Job[Payment]) {
+	payment := job.Value
+	ctx, span := worker.StartSpan(ctx, GetTracer(), jobTraceMode, "processPayments", job.Origin)
+	defer span.End()
+	jobDone := poolMetrics.Started(ctx, job.EnqueuedAt)
+
+	logCtx := workerLogger.WithContext(ctx)
+	logCtx.WithFields(log.Fields{
+		"workerID":  workerID,
+		"paymentID": payment.ID,
+	}).Info("Processing payment")
+
+	time.Sleep(2 * time.Second) // Simulate payment processing
+
+	logCtx.WithFields(log.Fields{
+		"workerID":  workerID,
+		"paymentID": payment.ID,
+	}).Info("Completed payment")
+	jobDone(nil)
+}
@@ -0,0 +1,18 @@
+package src
+
+import "SimpleMicroserviceProject/pkg/telemetry"
+
+// Business metrics of the service, validated in main.
+var (
+	paymentsReceived = paymentInstrument.Metrics.Counter(telemetry.MetricDef{
+		Name:        "payments.received",
+		Description: "The number of payments received",
+		Unit:        "{payment}",
+	})
+	paymentAmount = paymentInstrument.Metrics.Histogram(telemetry.MetricDef{
+		Name:        "payments.amount",
+		Description: "The amount of received payments",
+		Unit:        "{USD}",
+		Buckets:     []float64{10, 25, 50, 100, 250, 500, 1000},
+	})
+)
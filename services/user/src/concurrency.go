@@ -3,23 +3,35 @@ package src
 import (
 	"sync"
 
+	"SimpleMicroserviceProject/pkg/health"
+	"SimpleMicroserviceProject/pkg/worker"
+
 	"go.opentelemetry.io/otel/trace"
 )
 
 var (
 	tracer      trace.Tracer
-	userChannel = make(chan User, 10) // Buffered channel for users
-	wg          *sync.WaitGroup       // WaitGroup to synchronize goroutines
-	done        = make(chan struct{}) // Channel to signal workers to stop
+	userChannel = make(chan worker.Job[User], 10) // Buffered channel for users
+	wg          *sync.WaitGroup                   // WaitGroup to synchronize goroutines
+	done        = make(chan struct{})             // Channel to signal workers to stop
 )
 
+// workerMonitor lets the health checks see whether the workers are alive and making progress
+var workerMonitor = health.NewWorkerMonitor(ServiceName, func() int { return len(GetUserChannel()) })
+
+// poolMetrics exports queue depth, wait and processing times of the workers
+var poolMetrics = worker.NewMetrics(ServiceName, "users", func() int { return len(GetUserChannel()) }, workerMonitor.Alive)
+
+// jobTraceMode connects worker spans to the requests that queued the jobs
+var jobTraceMode = worker.TraceModeFromEnv()
+
 func GetTracer() trace.Tracer {
 	return tracer
 }
 
-func GetUserChannel() chan User {
+func GetUserChannel() chan worker.Job[User] {
 	if userChannel == nil {
-		userChannel = make(chan User, 10) // Buffered channel for users
+		userChannel = make(chan worker.Job[User], 10) // Buffered channel for users
 	}
 	return userChannel
 }
@@ -42,7 +54,7 @@ func SetTracer(t trace.Tracer) {
 	tracer = t
 }
 
-func SetUserChannel(oc chan User) {
+func SetUserChannel(oc chan worker.Job[User]) {
 	userChannel = oc
 }
 
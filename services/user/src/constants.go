@@ -1,3 +1,7 @@
 package src
 
-const ServiceName = "user"
+const (
+	ServiceName = "user"
+	// numWorkers is the number of workers processing the queue.
+	numWorkers = 3
+)
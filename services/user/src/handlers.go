@@ -3,12 +3,14 @@ package src
 import (
 	"context"
 	"net/http"
+	"strings"
 	"time"
 
+	applog "SimpleMicroserviceProject/pkg/log"
 	"SimpleMicroserviceProject/pkg/telemetry"
+	"SimpleMicroserviceProject/pkg/worker"
 
 	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/trace"
 
 	log "github.com/sirupsen/logrus"
@@ -16,6 +18,8 @@ import (
 
 var userInstrument = telemetry.GetNewInstrumentation(ServiceName)
 
+var workerLogger = applog.Component(applog.ComponentWorker)
+
 // HandleUser processes incoming user requests
 func HandleUser(w http.ResponseWriter, r *http.Request) {
 	ctx, span := userInstrument.Tracer.Start(r.Context(), "HandleUser /user")
@@ -26,7 +30,7 @@ func HandleUser(w http.ResponseWriter, r *http.Request) {
 		attribute.String("url", r.URL.Path)),
 	)
 	user := User{ID: time.Now().Nanosecond(), Email: "abc@example.com"}
-	GetUserChannel() <- user
+	GetUserChannel() <- worker.NewJob(ctx, user)
 
 	userInstrument.Logger.InfoContext(ctx, "Received new user", "result", log.Fields{
 		"userID": user.ID,
@@ -36,9 +40,9 @@ func HandleUser(w http.ResponseWriter, r *http.Request) {
 		"url":    r.URL.Path,
 	})
 
-	userCountAttr := attribute.Int("user.count", 1)
-	span.SetAttributes(userCountAttr)
-	userInstrument.Counter.Add(ctx, 1, metric.WithAttributes(userCountAttr))
+	span.SetAttributes(attribute.Int("user.count", 1))
+	_, domain, _ := strings.Cut(user.Email, "@")
+	usersReceived.Add(ctx, 1, attribute.String("email.domain", domain))
 	span.AddEvent("Completed user", trace.WithAttributes(
 		attribute.String("method", r.Method),
 		attribute.String("url", r.URL.Path)),
@@ -48,37 +52,46 @@ func HandleUser(w http.ResponseWriter, r *http.Request) {
 // ProcessUsers processes users in the userChannel
 func ProcessUsers(ctx context.Context, workerID int) {
 	defer GetWg().Done()
+	workerMonitor.Started()
+	defer workerMonitor.Stopped()
 
 	for {
 		select {
-		case user, ok := <-GetUserChannel():
+		case job, ok := <-GetUserChannel():
 			if !ok {
 				return // Channel closed
 			}
-
-			// Start a new span for processing users
-			var span trace.Span
-			ctx, span = GetTracer().Start(ctx, "processUsers")
-			defer span.End()
-
-			logCtx := log.WithContext(ctx)
-			logCtx.WithFields(log.Fields{
-				"workerID": workerID,
-				"userID":   user.ID,
-			}).Info("Processing user")
-
-			time.Sleep(2 * time.Second) // Simulate user processing
-
-			logCtx.WithFields(log.Fields{
-				"workerID": workerID,
-				"userID":   user.ID,
-			}).Info("Completed user")
+			processUser(ctx, workerID, job)
+			workerMonitor.Progress()
 
 		case <-GetDone():
-			log.WithFields(log.Fields{
+			workerLogger.WithFields(log.Fields{
 				"workerID": workerID,
 			}).Info("Shutting down worker")
 			return
 		}
 	}
 }
+
+// processUser handles one queued user in its own span, connected to the
+// request that queued it as configured by WORKER_TRACE_MODE.
+func processUser(ctx context.Context, workerID int, job worker.Job[User]) {
+	user := job.Value
+	ctx, span := worker.StartSpan(ctx, GetTracer(), jobTraceMode, "processUsers", job.Origin)
+	defer span.End()
+	jobDone := poolMetrics.Started(ctx, job.EnqueuedAt)
+
+	logCtx := workerLogger.WithContext(ctx)
+	logCtx.WithFields(log.Fields{
+		"workerID": workerID,
+		"userID":   user.ID,
+	}).Info("Processing user")
+
+	time.Sleep(2 * time.Second) // Simulate user processing
+
+	logCtx.WithFields(log.Fields{
+		"workerID": workerID,
+		"userID":   user.ID,
+	}).Info("Completed user")
+	jobDone(nil)
+}
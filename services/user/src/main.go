@@ -9,20 +9,32 @@ import (
 	"syscall"
 	"time"
 
+	"SimpleMicroserviceProject/pkg/admin"
 	"SimpleMicroserviceProject/pkg/db"
+	"SimpleMicroserviceProject/pkg/health"
+	"SimpleMicroserviceProject/pkg/lifecycle"
+	"SimpleMicroserviceProject/pkg/limiter"
 	"SimpleMicroserviceProject/pkg/log"
 	"SimpleMicroserviceProject/pkg/middleware"
 	"SimpleMicroserviceProject/pkg/telemetry"
+	"SimpleMicroserviceProject/pkg/tlsconfig"
+	"SimpleMicroserviceProject/pkg/worker"
 
 	"github.com/sirupsen/logrus"
 )
 
 func main() {
 	db.ConnectDatabase()
-	logger := log.InitLogger()
+	logger := log.InitLogger(ServiceName)
 	ctx := context.Background()
+	go log.HandleSignals(ctx)
 
-	if err := setupOpenTelemetry(ctx); err != nil {
+	if err := userInstrument.Metrics.Validate(); err != nil {
+		logger.WithError(err).Fatal("Invalid metric declarations")
+	}
+
+	otelShutdown, err := setupOpenTelemetry(ctx)
+	if err != nil {
 		logger.WithError(err).Fatal("Failed to setup OpenTelemetry")
 		return
 	}
@@ -30,15 +42,20 @@ func main() {
 	// Set up HTTP server with timeouts
 	server := middleware.GetHttpServer(ctx, []middleware.RouteMeta{
 		middleware.GetRouteMeta("/user", HandleUser, "Get random user"),
-		middleware.GetRouteMeta("/health", HandleHealthCheck, "Health check"),
-	})
+		middleware.GetRouteMeta("/health", HandleHealthCheck, "Health check").AsPriority(),
+		middleware.GetRouteMeta("/livez", health.Handler(health.Liveness), "Liveness probe").AsPriority(),
+		middleware.GetRouteMeta("/readyz", health.Handler(health.Readiness), "Readiness probe").AsPriority(),
+		middleware.GetRouteMeta("/startupz", health.Handler(health.Startup), "Startup probe").AsPriority(),
+	}, serverOptions(ctx, logger)...)
+
+	registerHealthChecks(ctx)
 
 	// Set up signal handling for graceful shutdown
 	shutdownChan := make(chan os.Signal, 1)
 	signal.Notify(shutdownChan, os.Interrupt, syscall.SIGTERM)
 
 	// Start worker goroutines to process users
-	for i := 1; i <= 3; i++ {
+	for i := 1; i <= numWorkers; i++ {
 		GetWg().Add(1)
 		go ProcessUsers(ctx, i)
 	}
@@ -46,47 +63,119 @@ func main() {
 	// Run the server in a goroutine
 	go func() {
 		logger.WithField("event", "startup").
-			WithField("port", 8080).
+			WithField("addr", server.Addr).
 			Info("Server is starting")
 		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			logger.WithError(err).Fatal("Server failed")
 		}
 	}()
 
+	adminServer := startAdminServer(ctx, logger)
+	health.MarkStarted()
+
 	<-shutdownChan // Wait for shutdown signal
-	handleShutdown(logger, ctx, server)
+	handleShutdown(logger, server, adminServer, otelShutdown)
 }
 
-func setupOpenTelemetry(ctx context.Context) error {
-	// Set up OpenTelemetry.
-	openTelemetryShutdown, tp, err := telemetry.SetupOTelSDK(ctx)
+// serverOptions sheds load once the worker queue fills up, applies the adaptive
+// concurrency limit from CONCURRENCY_LIMIT_* and enables TLS
+// (and mTLS when a CA is given) if certificates are configured through the TLS_* environment variables.
+func serverOptions(ctx context.Context, logger *logrus.Logger) []middleware.Option {
+	shedConfig := middleware.LoadShedderConfigFromEnv()
+	shedConfig.QueueDepth = func() int { return len(GetUserChannel()) }
+	shedConfig.QueueCapacity = cap(GetUserChannel())
+	opts := []middleware.Option{
+		middleware.WithLoadShedder(shedConfig),
+		middleware.WithMetrics(middleware.MetricsConfigFromEnv()),
+	}
+
+	if limitConfig := limiter.ConfigFromEnv(); limitConfig.Enabled() {
+		algorithm, err := limiter.NewAlgorithm(limitConfig)
+		if err != nil {
+			logger.WithError(err).Fatal("Invalid concurrency limit configuration")
+		}
+		opts = append(opts, middleware.WithConcurrencyLimit("", limiter.New(algorithm)))
+	}
+
+	tlsConfig := tlsconfig.FromEnv()
+	if !tlsConfig.Enabled() {
+		return opts
+	}
+
+	reloader, err := tlsconfig.NewReloader(tlsConfig)
 	if err != nil {
-		return err
+		logger.WithError(err).Fatal("Failed to load TLS certificates")
 	}
-	SetTracer(tp.Tracer(ServiceName))
-	// Handle shutdown properly so nothing leaks.
-	defer func() {
-		err = errors.Join(err, openTelemetryShutdown(context.Background()))
-	}()
-	return nil
+	go reloader.Watch(ctx)
+	return append(opts, middleware.WithTLS(reloader.ServerConfig()))
 }
 
-func handleShutdown(logger *logrus.Logger, ctx context.Context, server *http.Server) {
-	logger.Info("Shutdown signal received, stopping server...")
+// registerHealthChecks registers the checks behind /livez, /readyz and /startupz
+// and starts the background ones.
+func registerHealthChecks(ctx context.Context) {
+	health.Register(db.HealthCheck())
+	health.Register(telemetry.ExporterHealthCheck())
+	health.Register(workerMonitor.AliveCheck(numWorkers))
+	health.Register(workerMonitor.QueueCheck(30 * time.Second))
+	health.Start(ctx)
+}
 
-	// Gracefully shut down the server with a timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// startAdminServer starts the operational endpoints on ADMIN_ADDR, if configured.
+func startAdminServer(ctx context.Context, logger *logrus.Logger) *admin.Server {
+	adminConfig := admin.FromEnv()
+	if !adminConfig.Enabled() {
+		return nil
+	}
+
+	adminServer := admin.NewServer(ctx, adminConfig)
+	go func() {
+		logger.WithField("event", "startup").
+			WithField("addr", adminServer.Addr).
+			Info("Admin server is starting")
+		if err := adminServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.WithError(err).Fatal("Admin server failed")
+		}
+	}()
+	return adminServer
+}
 
-	// Shutdown the server
-	if err := server.Shutdown(ctx); err != nil {
-		logger.WithError(err).Fatal("Server Shutdown Failed")
+func setupOpenTelemetry(ctx context.Context) (func(context.Context) error, error) {
+	// Set up OpenTelemetry. The returned shutdown flushes the exporters and
+	// runs last during shutdown so nothing recorded while draining is lost.
+	openTelemetryShutdown, tp, err := telemetry.SetupOTelSDK(ctx, telemetry.WithServiceName(ServiceName))
+	if err != nil {
+		return nil, err
 	}
+	SetTracer(tp.Tracer(ServiceName))
+	return openTelemetryShutdown, nil
+}
 
-	// Signal workers to stop and wait for them to finish processing
-	close(GetDone())
-	close(GetUserChannel())
-	GetWg().Wait()
+func handleShutdown(logger *logrus.Logger, server *middleware.Server, adminServer *admin.Server, otelShutdown func(context.Context) error) {
+	logger.Info("Shutdown signal received, draining...")
 
+	coordinator := lifecycle.NewCoordinator(logger)
+	// Fail readiness first and give the endpoints time to drop this pod.
+	coordinator.Add("mark_not_ready", 0, func(context.Context) error {
+		health.MarkDraining()
+		return nil
+	})
+	coordinator.Add("drain_wait", lifecycle.DrainPeriod(), lifecycle.Wait(lifecycle.DrainPeriod()))
+	coordinator.Add("http_server", 10*time.Second, server.Shutdown)
+	// Workers finish what is already queued, whatever is left is reported. The
+	// queue stays open while handlers may still be sending to it.
+	coordinator.Add("workers", lifecycle.WorkerTimeout(), lifecycle.DrainQueue(GetUserChannel(), GetWg(), GetDone(), func() bool { return coordinator.Succeeded("http_server") }, func(job worker.Job[User]) {
+		poolMetrics.Dropped(context.Background(), "shutdown")
+		logger.WithField("user", job.Value).Warn("Unprocessed user dropped at shutdown")
+	}))
+	// The admin server stays available until the service itself has drained.
+	if adminServer != nil {
+		coordinator.Add("admin_server", 5*time.Second, adminServer.Shutdown)
+	}
+	coordinator.Add("telemetry_flush", 5*time.Second, otelShutdown)
+
+	if err := coordinator.Shutdown(context.Background()); err != nil {
+		logger.WithError(err).Error("Shutdown completed with errors")
+		return
+	}
 	logger.Info("Server gracefully stopped.")
 }
@@ -0,0 +1,15 @@
+package src
+
+import "SimpleMicroserviceProject/pkg/telemetry"
+
+// Business metrics of the service, validated in main.
+var (
+	usersReceived = userInstrument.Metrics.Counter(telemetry.MetricDef{
+		Name:        "users.received",
+		Description: "The number of users received",
+		Unit:        "{user}",
+		// Domains are unbounded, beyond the first 50 they are aggregated.
+		AttributeKeys:  []string{"email.domain"},
+		MaxCardinality: 50,
+	})
+)